@@ -6,14 +6,23 @@
 package grape
 
 import (
+	"os"
+	"sync"
+	"time"
+
 	"go.osspkg.com/config"
 	"go.osspkg.com/console"
-	"go.osspkg.com/events"
+	"go.osspkg.com/errors"
 	config2 "go.osspkg.com/grape/config"
 	"go.osspkg.com/grape/container"
 	"go.osspkg.com/grape/env"
-	"go.osspkg.com/grape/internal"
+	"go.osspkg.com/grape/internal/pidfile"
+	"go.osspkg.com/grape/internal/sdnotify"
+	"go.osspkg.com/grape/internal/upgrade"
+	"go.osspkg.com/grape/lifecycle"
+	"go.osspkg.com/grape/metrics"
 	"go.osspkg.com/grape/reflect"
+	"go.osspkg.com/grape/tracing"
 	"go.osspkg.com/logx"
 	"go.osspkg.com/xc"
 )
@@ -24,9 +33,20 @@ type Grape interface {
 	Logger(log logx.Logger) Grape
 	Modules(modules ...interface{}) Grape
 	ConfigResolvers(res ...config.Resolver) Grape
-	ConfigFile(filename string) Grape
+	ConfigFile(filenames ...string) Grape
 	ConfigModels(configs ...interface{}) Grape
+	ConfigEnvPrefix(prefix string) Grape
+	ConfigStrict(mode config2.StrictMode) Grape
+	ConfigWatch(interval time.Duration) Grape
+	ConfigSecretKey(filename string) Grape
+	ConfigCommands() Grape
 	PidFile(filename string) Grape
+	Signals(s Signals) Grape
+	ShutdownTimeout(timeout time.Duration) Grape
+	DumpDir(dir string) Grape
+	SlowStepThreshold(threshold time.Duration) Grape
+	Observer(observers ...lifecycle.Observer) Grape
+	Tracing(exporter tracing.Exporter) Grape
 	Run()
 	Invoke(call interface{})
 	Call(call interface{})
@@ -34,31 +54,81 @@ type Grape interface {
 }
 
 type _grape struct {
-	appName        string
-	configFilePath string
-	pidFilePath    string
-	resolvers      []config.Resolver
-	configs        Modules
-	modules        Modules
-	packages       container.TContainer
-	logHandler     *_log
-	log            logx.Logger
-	appContext     xc.Context
-	exitFunc       func(code int)
+	appName             string
+	configFiles         []string
+	envPrefix           string
+	strictMode          config2.StrictMode
+	pidFilePath         string
+	pidFile             *pidfile.File
+	resolvers           []config.Resolver
+	configs             Modules
+	configTemplates     Modules
+	configWatchInterval time.Duration
+	watcher             config2.WatcherNotifier
+	secrets             *config2.Secrets
+	configCommands      bool
+	modules             Modules
+	packages            container.TContainer
+	logHandler          *_log
+	log                 logx.Logger
+	appContext          xc.Context
+	notifier            *sdnotify.Notifier
+	listeners           *_listeners
+	inherited           *upgrade.Inheritance
+	upgraded            bool
+	abandoned           bool
+	upgrading           int32
+	restarts            int
+	signals             Signals
+	shutdownTimeout     time.Duration
+	stopSignals         int32
+	stopped             chan struct{}
+	reloadMux           sync.Mutex
+	forceExit           func(code int)
+	dumpDir             string
+	slowStepThreshold   time.Duration
+	events              *lifecycle.Hub
+	metrics             metrics.Registry
+	startupDuration     metrics.Gauge
+	tracer              *tracing.Tracer
+	traceExporter       tracing.Exporter
+	exitFunc            func(code int)
 }
 
 // New create application
 func New(appName string) Grape {
 	ctx := xc.New()
-	return &_grape{
+	events := lifecycle.NewHub()
+	packages := container.New(ctx)
+	packages.Observe(events)
+	app := &_grape{
 		appName:    appName,
 		resolvers:  make([]config.Resolver, 0, 2),
 		modules:    Modules{},
 		configs:    Modules{},
-		packages:   container.New(ctx),
+		watcher:    config2.NewWatcher(),
+		secrets:    config2.NewSecrets(""),
+		packages:   packages,
+		events:     events,
 		appContext: ctx,
+		notifier:   sdnotify.New(),
+		listeners:  newListeners(),
 		exitFunc:   func(_ int) {},
+		forceExit:  os.Exit,
+
+		signals:         defaultSignals(),
+		shutdownTimeout: defaultShutdownTimeout,
+		metrics:         metrics.NewRegistry(),
 	}
+	app.registerMetrics()
+	return app
+}
+
+// Observer add observers of lifecycle events of dependencies, services and config,
+// observers are called synchronously and must not block
+func (a *_grape) Observer(observers ...lifecycle.Observer) Grape {
+	a.events.Observe(observers...)
+	return a
 }
 
 // Logger setup logger
@@ -80,9 +150,10 @@ func (a *_grape) Modules(modules ...interface{}) Grape {
 	return a
 }
 
-// ConfigFile set config file path
-func (a *_grape) ConfigFile(filename string) Grape {
-	a.configFilePath = filename
+// ConfigFile set config files, directories or glob patterns which are deep-merged in order,
+// the profile file for the current env (config.prod.yaml for config.yaml) is merged automatically
+func (a *_grape) ConfigFile(filenames ...string) Grape {
+	a.configFiles = append(a.configFiles, filenames...)
 	return a
 }
 
@@ -94,6 +165,32 @@ func (a *_grape) ConfigModels(configs ...interface{}) Grape {
 	return a
 }
 
+// ConfigEnvPrefix enable overriding of config values by environment variables with the prefix,
+// names are built from yaml tags: MYAPP + log.level = MYAPP_LOG_LEVEL
+func (a *_grape) ConfigEnvPrefix(prefix string) Grape {
+	a.envPrefix = prefix
+	return a
+}
+
+// ConfigStrict set behavior for config keys which are not used by config.Config and config models
+func (a *_grape) ConfigStrict(mode config2.StrictMode) Grape {
+	a.strictMode = mode
+	return a
+}
+
+// ConfigWatch set interval for checking changes of config files, the config is reloaded on change,
+// SIGHUP reloads the config regardless of this setting
+func (a *_grape) ConfigWatch(interval time.Duration) Grape {
+	a.configWatchInterval = interval
+	return a
+}
+
+// ConfigSecretKey set key file for decryption of enc:// secret references in fields tagged as secrets
+func (a *_grape) ConfigSecretKey(filename string) Grape {
+	a.secrets = config2.NewSecrets(filename)
+	return a
+}
+
 // ConfigResolvers set configs resolvers
 func (a *_grape) ConfigResolvers(crs ...config.Resolver) Grape {
 	for _, r := range crs {
@@ -102,11 +199,22 @@ func (a *_grape) ConfigResolvers(crs ...config.Resolver) Grape {
 	return a
 }
 
+// PidFile set path of the pid file, it is locked while the application is running
 func (a *_grape) PidFile(filename string) Grape {
 	a.pidFilePath = filename
 	return a
 }
 
+func (a *_grape) removePidFile() {
+	if a.pidFile == nil {
+		return
+	}
+	if err := a.pidFile.Remove(); err != nil {
+		a.log.Error("Remove pid file", "err", err, "file", a.pidFilePath)
+	}
+	a.pidFile = nil
+}
+
 func (a *_grape) ExitFunc(v func(code int)) Grape {
 	a.exitFunc = v
 	return a
@@ -114,7 +222,14 @@ func (a *_grape) ExitFunc(v func(code int)) Grape {
 
 // Run application with all dependencies
 func (a *_grape) Run() {
+	if a.runCommand(os.Args[1:]) {
+		return
+	}
+	started := time.Now()
+	actions, err := a.signalActions()
+	console.FatalIfErr(err, "Signals")
 	a.prepareConfig(false)
+	a.stopped = make(chan struct{})
 
 	result := a.steps(
 		[]step{
@@ -128,21 +243,44 @@ func (a *_grape) Run() {
 			},
 		},
 		func(er bool) {
+			a.reportTimings(timingStartup)
+			a.exportTrace()
 			if er {
 				a.appContext.Close()
 				return
 			}
-			go events.OnStopSignal(a.appContext.Close)
+			a.observeStartup(started)
+			a.completeUpgrade()
+			go a.watchSignals(actions)
+			go a.watchReload()
+			go a.watchdog()
+			a.notify(sdnotify.Ready, sdnotify.Status("Running"))
 			<-a.appContext.Done()
+			lifecycle.Emit(a.events, lifecycle.ShutdownStarted, a.appName, time.Time{}, nil)
+			if !a.upgraded {
+				a.notify(sdnotify.Stopping, sdnotify.Status("Stopping"))
+			}
 		},
 		[]step{
 			{
 				Message: "Stop dependencies",
-				Call:    func() error { return a.packages.Stop() },
+				Call:    a.stopPackages,
 			},
 		},
 	)
-	console.FatalIfErr(a.logHandler.Close(), "close log file")
+	a.reportTimings(timingShutdown)
+	a.exportTrace()
+	if err := a.listeners.Close(); err != nil {
+		a.log.Error("Close listeners", "err", err)
+	}
+	a.removePidFile()
+	close(a.stopped)
+	a.events.Close()
+	a.logHandler.Flush()
+	// abandoned services may still write to the log, the files are closed on exit
+	if !a.abandoned {
+		console.FatalIfErr(a.logHandler.Close(), "close log file")
+	}
 	if result {
 		a.exitFunc(1)
 	}
@@ -165,13 +303,17 @@ func (a *_grape) Invoke(call interface{}) {
 				Call: func() error { return a.packages.Invoke(call) },
 			},
 		},
-		func(_ bool) {},
+		func(_ bool) {
+			a.reportTimings(timingStartup)
+		},
 		[]step{
 			{
 				Call: func() error { return a.packages.Stop() },
 			},
 		},
 	)
+	a.reportTimings(timingShutdown)
+	a.logHandler.Flush()
 	console.FatalIfErr(a.logHandler.Close(), "close log file")
 	if result {
 		a.exitFunc(1)
@@ -198,13 +340,17 @@ func (a *_grape) Call(call interface{}) {
 				Call: func() error { return a.packages.Start() },
 			},
 		},
-		func(_ bool) {},
+		func(_ bool) {
+			a.reportTimings(timingStartup)
+		},
 		[]step{
 			{
 				Call: func() error { return a.packages.Stop() },
 			},
 		},
 	)
+	a.reportTimings(timingShutdown)
+	a.logHandler.Flush()
 	console.FatalIfErr(a.logHandler.Close(), "close log file")
 	if result {
 		a.exitFunc(1)
@@ -213,21 +359,12 @@ func (a *_grape) Call(call interface{}) {
 }
 
 func (a *_grape) prepareConfig(interactive bool) {
-	var err error
-	appConfig := config2.Default()
-
-	// read config file
-	resolver := config.New(a.resolvers...)
-	if len(a.configFilePath) > 0 {
-		console.FatalIfErr(resolver.OpenFile(a.configFilePath), "Open config file: %s", a.configFilePath)
-	}
-	console.FatalIfErr(resolver.Build(), "Prepare config file: %s", a.configFilePath)
-	if !interactive {
-		console.FatalIfErr(resolver.Decode(appConfig), "Decode config file: %s", a.configFilePath)
-	}
+	appConfig, configs, doc := a.loadConfig(interactive)
 
 	// init logger
-	a.logHandler = newLog(a.appName, appConfig.Log)
+	var err error
+	a.logHandler, err = newLog(a.appName, resolveLogFormat(appConfig.Log, appConfig.Env))
+	console.FatalIfErr(err, "Init logger")
 	if a.log == nil {
 		a.log = logx.Default()
 	}
@@ -236,21 +373,66 @@ func (a *_grape) prepareConfig(interactive bool) {
 		env.ENV(appConfig.Env),
 	)
 
-	// decode all configs
-	var configs []interface{}
-	configs, err = reflect.TypingPtr(a.configs, func(c interface{}) error {
-		return resolver.Decode(c)
-	})
-	console.FatalIfErr(err, "Decode config file: %s", a.configFilePath)
+	console.FatalIfErr(a.checkUnknownKeys(doc, configs), "Config files: %s", a.configFileNames())
 	a.modules = a.modules.Add(configs...)
 
-	if !interactive && len(a.pidFilePath) > 0 {
-		console.FatalIfErr(internal.SavePidToFile(a.pidFilePath), "Create pid file: %s", a.pidFilePath)
+	if !interactive {
+		console.FatalIfErr(a.inherit(), "Inherit descriptors of the parent process")
+	}
+	if !interactive {
+		console.FatalIfErr(a.listeners.Activate(), "Socket activation")
+		console.FatalIfErr(a.listeners.Open(appConfig.Listeners), "Open listeners")
+	}
+	// the pid file is created after the listeners are opened so a failed start doesn't leave it,
+	// the pid file of the parent process is taken after successful start
+	if !interactive && len(a.pidFilePath) > 0 && (a.inherited == nil || a.inherited.PidFile == nil) {
+		a.pidFile, err = pidfile.Create(a.pidFilePath)
+		console.FatalIfErr(err, "Create pid file: %s", a.pidFilePath)
 	}
 	a.modules = a.modules.Add(
 		func() logx.Logger { return a.log },
 		func() xc.Context { return a.appContext },
+		func() config2.Watcher { return a.watcher },
+		func() LogLevelController { return a.logHandler },
+		func() LoggerFactory { return a.logHandler },
+		func() Listeners { return a.listeners },
+		func() StateDumper { return a },
+		func() lifecycle.Subscriber { return a.events },
+		func() metrics.Registry { return a.metrics },
+	)
+}
+
+// loadConfig config stage: read all sources, decode and validate config.Config and config models
+func (a *_grape) loadConfig(interactive bool) (*config2.Config, []interface{}, config2.Document) {
+	appConfig := config2.Default()
+
+	// read config files
+	doc, err := a.readConfig()
+	console.FatalIfErr(err, "Prepare config files: %s", a.configFileNames())
+	if !interactive {
+		console.FatalIfErr(a.decodeConfig(doc, appConfig), "Decode config files: %s", a.configFileNames())
+	}
+
+	// decode all configs
+	var (
+		configs     []interface{}
+		validateErr error
 	)
+	if !interactive {
+		validateErr = config2.Validate(appConfig)
+	}
+	a.configTemplates = copyModels(a.configs)
+	configs, err = reflect.TypingPtr(a.configs, func(c interface{}) error {
+		if err0 := a.decodeConfig(doc, c); err0 != nil {
+			return err0
+		}
+		validateErr = errors.Wrap(validateErr, config2.Validate(c))
+		return nil
+	})
+	console.FatalIfErr(err, "Decode config files: %s", a.configFileNames())
+	console.FatalIfErr(validateErr, "Validate config files: %s", a.configFileNames())
+
+	return appConfig, configs, doc
 }
 
 type step struct {
@@ -265,7 +447,7 @@ func (a *_grape) steps(up []step, wait func(bool), down []step) bool {
 		if len(s.Message) > 0 {
 			a.log.Info(s.Message)
 		}
-		if err := s.Call(); err != nil {
+		if err := a.traceStep(s); err != nil {
 			a.log.Error(s.Message, "err", err)
 			erc++
 			break
@@ -278,7 +460,7 @@ func (a *_grape) steps(up []step, wait func(bool), down []step) bool {
 		if len(s.Message) > 0 {
 			a.log.Info(s.Message)
 		}
-		if err := s.Call(); err != nil {
+		if err := a.traceStep(s); err != nil {
 			a.log.Error(s.Message, "err", err)
 			erc++
 		}
@@ -6,8 +6,11 @@
 package grape_test
 
 import (
+	"net"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"go.osspkg.com/casecheck"
 	"go.osspkg.com/grape"
@@ -58,6 +61,35 @@ func TestUnit_AppInvoke(t *testing.T) {
 	casecheck.Equal(t, "[Struct1.Do][Struct2.Do]Done", out)
 }
 
+type TestConfigDB struct {
+	DB struct {
+		DSN  string `yaml:"dsn"`
+		Pool int    `yaml:"pool"`
+	} `yaml:"db"`
+}
+
+func TestUnit_AppConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":      "env: prod\ndb:\n  dsn: base\n  pool: 1\n",
+		"config.prod.yaml": "db:\n  pool: 10\n",
+		"config.dev.yaml":  "db:\n  pool: 2\n",
+		"extra.yaml":       "db:\n  dsn: extra\n",
+	}
+	for name, data := range files {
+		casecheck.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0600))
+	}
+
+	var result TestConfigDB
+	grape.New("testapp").
+		ConfigFile(filepath.Join(dir, "config.yaml"), filepath.Join(dir, "extra.yaml")).
+		ConfigModels(&TestConfigDB{}).
+		Invoke(func(c *TestConfigDB) { result = *c })
+
+	casecheck.Equal(t, "extra", result.DB.DSN)
+	casecheck.Equal(t, 10, result.DB.Pool)
+}
+
 type Struct1 struct{ s *Struct2 }
 
 func NewStruct1(s2 *Struct2) *Struct1 {
@@ -72,3 +104,39 @@ func (s *Struct1) Do2(v *string) {
 type Struct2 struct{}
 
 func (*Struct2) Do(v *string) { *v += "[Struct2.Do]" }
+
+func TestUnit_AppSystemdNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	grape.New("testapp").ExitFunc(func(code int) {
+		casecheck.Equal(t, 0, code)
+	}).Modules(func(ctx xc.Context) {
+		ctx.Close()
+	}).Run()
+
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	for _, want := range []string{"READY=1\nSTATUS=Running", "STOPPING=1\nSTATUS=Stopping"} {
+		n, err0 := conn.Read(buf)
+		casecheck.NoError(t, err0)
+		casecheck.Equal(t, want, string(buf[:n]))
+	}
+}
+
+func TestUnit_AppListeners(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	casecheck.NoError(t, os.WriteFile(filename, []byte("listeners:\n  http:\n    address: 127.0.0.1:0\n"), 0600))
+
+	addr := ""
+	grape.New("testapp").ConfigFile(filename).Modules(func(ctx xc.Context, l grape.Listeners) {
+		if v, err := l.Listener("http"); err == nil {
+			addr = v.Addr().String()
+		}
+		ctx.Close()
+	}).Run()
+	casecheck.NotEqual(t, "", addr)
+}
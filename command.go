@@ -0,0 +1,85 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"strings"
+
+	"go.osspkg.com/console"
+	config2 "go.osspkg.com/grape/config"
+)
+
+const (
+	// FlagConfigDump print the effective config and exit: --config-dump[=yaml|json]
+	FlagConfigDump = "config-dump"
+	// FlagConfigSchema print JSON Schema of the config file and exit: --config-schema
+	FlagConfigSchema = "config-schema"
+	// FlagConfigDocs print Markdown reference of the config file and exit: --config-docs
+	FlagConfigDocs = "config-docs"
+)
+
+// ConfigCommands enable built-in commands of the config in arguments of the command line:
+// --config-dump, --config-schema and --config-docs, the command is run instead of the application
+func (a *_grape) ConfigCommands() Grape {
+	a.configCommands = true
+	return a
+}
+
+// runCommand run built-in command from arguments if commands are enabled, returns true if the command was found
+func (a *_grape) runCommand(args []string) bool {
+	if !a.configCommands {
+		return false
+	}
+	if format, ok := lookupFlag(args, FlagConfigDump); ok {
+		a.dumpConfig(format)
+		return true
+	}
+	if _, ok := lookupFlag(args, FlagConfigSchema); ok {
+		a.printConfigReference(config2.JSONSchema)
+		return true
+	}
+	if _, ok := lookupFlag(args, FlagConfigDocs); ok {
+		a.printConfigReference(config2.Markdown)
+		return true
+	}
+	return false
+}
+
+// dumpConfig print config.Config and all config models with masked secrets, modules are not registered
+func (a *_grape) dumpConfig(format string) {
+	appConfig, configs, _ := a.loadConfig(false)
+	doc, err := config2.Export(append([]interface{}{appConfig}, configs...)...)
+	console.FatalIfErr(err, "Dump config files: %s", a.configFileNames())
+	console.FatalIfErr(doc.Write(os.Stdout, format), "Dump config files: %s", a.configFileNames())
+	a.exitFunc(0)
+}
+
+// printConfigReference print reference of config.Config and all config models built by the generator
+func (a *_grape) printConfigReference(generate func(models ...interface{}) ([]byte, error)) {
+	b, err := generate(append([]interface{}{config2.Default()}, a.configs...)...)
+	console.FatalIfErr(err, "Generate config reference")
+	_, err = os.Stdout.Write(b)
+	console.FatalIfErr(err, "Generate config reference")
+	a.exitFunc(0)
+}
+
+// lookupFlag find flag in forms: -name, --name, -name=value, --name=value
+func lookupFlag(args []string, name string) (string, bool) {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+)
+
+func TestUnit_RunCommand(t *testing.T) {
+	cases := []struct {
+		name    string
+		enabled bool
+		args    []string
+		want    bool
+	}{
+		{name: "disabled", args: []string{"--config-dump=json"}, want: false},
+		{name: "enabled", enabled: true, args: []string{"-v", "--config-dump=json"}, want: true},
+		{name: "app args", enabled: true, args: []string{"run", "--", "--config-dump=json"}, want: false},
+		{name: "no command", enabled: true, args: []string{"--config"}, want: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "config.yaml")
+			casecheck.NoError(t, os.WriteFile(filename, []byte("env: dev\n"), 0600))
+			a := New("test").(*_grape)
+			a.ConfigFile(filename)
+			exitCode := -1
+			a.ExitFunc(func(code int) { exitCode = code })
+			if c.enabled {
+				a.ConfigCommands()
+			}
+
+			stdout := os.Stdout
+			out, err := os.Create(filepath.Join(dir, "stdout"))
+			casecheck.NoError(t, err)
+			os.Stdout = out
+			got := a.runCommand(c.args)
+			os.Stdout = stdout
+			casecheck.NoError(t, out.Close())
+
+			casecheck.Equal(t, c.want, got)
+			if c.want {
+				casecheck.Equal(t, 0, exitCode)
+			} else {
+				casecheck.Equal(t, -1, exitCode)
+			}
+		})
+	}
+}
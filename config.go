@@ -0,0 +1,139 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.osspkg.com/config"
+	"go.osspkg.com/errors"
+	config2 "go.osspkg.com/grape/config"
+)
+
+func (a *_grape) configFileNames() string {
+	return strings.Join(a.configFiles, ", ")
+}
+
+// readConfig read and merge all config files, then merge the profile files for the resolved env
+func (a *_grape) readConfig() (config2.Document, error) {
+	files, err := config2.ExpandFiles(a.configFiles...)
+	if err != nil {
+		return nil, err
+	}
+	files = skipProfileFiles(files)
+
+	doc := config2.Document{}
+	for _, filename := range files {
+		if err = a.mergeConfigFile(doc, filename); err != nil {
+			return nil, err
+		}
+	}
+
+	root := config2.Default()
+	if err = a.decodeConfig(doc, root); err != nil {
+		return nil, err
+	}
+	for _, filename := range files {
+		profile := config2.ProfileFile(filename, root.Env)
+		if len(profile) == 0 {
+			continue
+		}
+		if _, err = os.Stat(profile); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if err = a.mergeConfigFile(doc, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func (a *_grape) mergeConfigFile(doc config2.Document, filename string) error {
+	resolver := config.New(a.resolvers...)
+	if err := resolver.OpenFile(filename); err != nil {
+		return errors.Wrapf(err, "open config file [%s]", filename)
+	}
+	if err := resolver.Build(); err != nil {
+		return errors.Wrapf(err, "build config file [%s]", filename)
+	}
+	data := make(map[string]interface{})
+	if err := resolver.Decode(&data); err != nil {
+		return errors.Wrapf(err, "decode config file [%s]", filename)
+	}
+	doc.Merge(data)
+	return nil
+}
+
+// decodeConfig apply values to the config model in order: defaults, config files, environment,
+// then resolve secret references of the fields tagged as secrets
+func (a *_grape) decodeConfig(doc config2.Document, c interface{}) error {
+	if err := config2.ApplyDefaults(c); err != nil {
+		return err
+	}
+	if err := doc.Decode(c); err != nil {
+		return err
+	}
+	if err := config2.ApplyEnv(a.envPrefix, c); err != nil {
+		return err
+	}
+	return a.secrets.Resolve(c)
+}
+
+// checkUnknownKeys check keys of config files which are not used by config models,
+// the error is returned in the fail mode, keys are logged in the warn mode
+func (a *_grape) checkUnknownKeys(doc config2.Document, configs []interface{}) error {
+	if a.strictMode == config2.StrictOff {
+		return nil
+	}
+	keys := config2.UnknownKeys(doc, append([]interface{}{&config2.Config{}}, configs...)...)
+	if len(keys) == 0 {
+		return nil
+	}
+	if a.strictMode == config2.StrictFail {
+		return fmt.Errorf("unknown keys in config files: %s", strings.Join(keys, ", "))
+	}
+	for _, key := range keys {
+		a.log.Warn("Unknown key in config files", "files", a.configFileNames(), "key", key)
+	}
+	return nil
+}
+
+// skipProfileFiles remove profile files (config.prod.yaml) of the other files from the list,
+// they are merged only for the matched env
+func skipProfileFiles(files []string) []string {
+	index := make(map[string]struct{}, len(files))
+	for _, filename := range files {
+		index[filename] = struct{}{}
+	}
+	result := make([]string, 0, len(files))
+	for _, filename := range files {
+		if isProfileFile(filename, index) {
+			continue
+		}
+		result = append(result, filename)
+	}
+	return result
+}
+
+func isProfileFile(filename string, index map[string]struct{}) bool {
+	ext := ""
+	if i := strings.LastIndex(filename, "."); i > 0 {
+		ext = filename[i:]
+	}
+	base := strings.TrimSuffix(filename, ext)
+	i := strings.LastIndex(base, ".")
+	if i <= 0 {
+		return false
+	}
+	_, ok := index[base[:i]+ext]
+	return ok
+}
@@ -5,19 +5,87 @@
 
 package config
 
-import "go.osspkg.com/logx"
+import (
+	"time"
+
+	"go.osspkg.com/logx"
+)
 
 type (
 	// Config config model
 	Config struct {
 		Env string    `yaml:"env"`
 		Log LogConfig `yaml:"log"`
+		// Listeners network listeners opened by the application, the key is the name of the listener,
+		// listeners with the same name received from systemd socket activation are used instead
+		Listeners map[string]ListenerConfig `yaml:"listeners,omitempty"`
+	}
+
+	// ListenerConfig address of the network listener
+	ListenerConfig struct {
+		// Network tcp, tcp4, tcp6 or unix, default tcp
+		Network string `yaml:"network,omitempty"`
+		Address string `yaml:"address" required:"true"`
 	}
 
 	LogConfig struct {
 		Level    uint32 `yaml:"level"`
 		FilePath string `yaml:"file_path,omitempty"`
-		Format   string `yaml:"format"`
+		// Format string, json, logfmt, pretty, syslog or auto - pretty for terminal in DEV env, otherwise string
+		Format string          `yaml:"format"`
+		Rotate LogRotateConfig `yaml:"rotate,omitempty"`
+		Syslog LogSyslogConfig `yaml:"syslog,omitempty"`
+		// Sinks list of outputs, if it is empty the output is described by the fields above
+		Sinks []LogSinkConfig `yaml:"sinks,omitempty"`
+		// Modules level of the named loggers, the key is the module name or its prefix
+		Modules map[string]uint32 `yaml:"modules,omitempty"`
+		Async   LogAsyncConfig    `yaml:"async,omitempty"`
+	}
+
+	// LogSinkConfig one output of the logger with own level and format
+	LogSinkConfig struct {
+		// Level max level of the sink, by default it is the level of the logger
+		Level    *uint32         `yaml:"level,omitempty"`
+		FilePath string          `yaml:"file_path"`
+		Format   string          `yaml:"format"`
+		Rotate   LogRotateConfig `yaml:"rotate,omitempty"`
+		Syslog   LogSyslogConfig `yaml:"syslog,omitempty"`
+	}
+
+	// LogRotateConfig rotation of the log file, works only for regular files
+	LogRotateConfig struct {
+		// MaxSize max size of the log file in megabytes, 0 - disabled
+		MaxSize int64 `yaml:"max_size,omitempty"`
+		// Interval rotation period (24h - daily), 0 - disabled
+		Interval time.Duration `yaml:"interval,omitempty"`
+		// MaxBackups count of rotated files to keep, 0 - keep all
+		MaxBackups int `yaml:"max_backups,omitempty"`
+		// Compress gzip rotated files
+		Compress bool `yaml:"compress,omitempty"`
+	}
+
+	// LogAsyncConfig lines are written to sinks in background, the caller does not wait for slow outputs
+	LogAsyncConfig struct {
+		Enabled bool `yaml:"enabled"`
+		// Queue count of lines waiting for writing to every sink, default 1024
+		Queue int `yaml:"queue,omitempty"`
+		// Policy for the full queue: block - wait for free space, drop - drop new lines, default block
+		Policy string `yaml:"policy,omitempty"`
+	}
+
+	// LogSyslogConfig output to syslog in RFC 5424 format, the file path is the address of the server:
+	// udp://host:514, tcp://host:601, unix:///dev/log, empty or a file path (/dev/stdout) - local syslog daemon
+	LogSyslogConfig struct {
+		// Facility name: kern, user, daemon, local0 ... local7, default user
+		Facility string `yaml:"facility,omitempty"`
+		// Buffer count of messages kept while the server is not available
+		Buffer int `yaml:"buffer,omitempty"`
+		// Timeout of dial and write
+		Timeout time.Duration `yaml:"timeout,omitempty"`
+		// Severities severity of the log levels: the key is fatal, error, warn, info, debug,
+		// the value is emerg, alert, crit, err, warning, notice, info, debug,
+		// by default: crit, err, warning, info, debug
+		Severities map[string]string `yaml:"severities,omitempty"`
 	}
 )
 
@@ -27,7 +95,22 @@ func Default() *Config {
 		Log: LogConfig{
 			Level:    logx.LevelDebug,
 			FilePath: "/dev/stdout",
-			Format:   "string",
+			Format:   "auto",
+		},
+	}
+}
+
+// GetSinks returns all outputs of the logger
+func (v LogConfig) GetSinks() []LogSinkConfig {
+	if len(v.Sinks) > 0 {
+		return v.Sinks
+	}
+	return []LogSinkConfig{
+		{
+			FilePath: v.FilePath,
+			Format:   v.Format,
+			Rotate:   v.Rotate,
+			Syslog:   v.Syslog,
 		},
 	}
 }
@@ -0,0 +1,120 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document merged tree of all config files
+type Document map[string]interface{}
+
+// Merge deep merge the source into the document, values of the source win,
+// nested maps are merged and all other values (including lists) are replaced
+func (d Document) Merge(src map[string]interface{}) {
+	mergeMap(d, src)
+}
+
+func mergeMap(dst, src map[string]interface{}) {
+	for key, value := range src {
+		value = normalize(value)
+		srcMap, ok := value.(map[string]interface{})
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		dstMap, ok := dst[key].(map[string]interface{})
+		if !ok {
+			dstMap = make(map[string]interface{}, len(srcMap))
+			dst[key] = dstMap
+		}
+		mergeMap(dstMap, srcMap)
+	}
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprintf("%v", key)] = normalize(item)
+		}
+		return result
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// Decode decode the document into the config model, fields which are absent in the document are not changed
+func (d Document) Decode(target interface{}) error {
+	b, err := yaml.Marshal(map[string]interface{}(d))
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, target)
+}
+
+// ExpandFiles resolve the list of config files: directories are replaced with their *.yaml and *.yml files,
+// glob patterns with the matched files, both in lexical order
+func ExpandFiles(patterns ...string) ([]string, error) {
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.ContainsAny(pattern, "*?[") {
+			list, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config pattern [%s]: %w", pattern, err)
+			}
+			sort.Strings(list)
+			result = append(result, list...)
+			continue
+		}
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			result = append(result, pattern)
+			continue
+		}
+		list := make([]string, 0, 4)
+		for _, ext := range []string{"*.yaml", "*.yml"} {
+			files, err := filepath.Glob(filepath.Join(pattern, ext))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, files...)
+		}
+		sort.Strings(list)
+		result = append(result, list...)
+	}
+	return result, nil
+}
+
+// ProfileFile build the profile name of config file for the environment (config.yaml + prod = config.prod.yaml)
+func ProfileFile(filename, env string) string {
+	env = strings.ToLower(strings.TrimSpace(env))
+	if len(env) == 0 {
+		return ""
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + env + ext
+}
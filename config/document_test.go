@@ -0,0 +1,58 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+func TestUnit_DocumentMerge(t *testing.T) {
+	doc := config.Document{}
+	doc.Merge(map[string]interface{}{
+		"env": "dev",
+		"db":  map[string]interface{}{"dsn": "base", "pool": 1, "hosts": []interface{}{"a", "b"}},
+	})
+	doc.Merge(map[string]interface{}{
+		"env": "prod",
+		"db":  map[interface{}]interface{}{"pool": 10, "hosts": []interface{}{"c"}},
+	})
+
+	model := &testStrictModel{}
+	casecheck.NoError(t, doc.Decode(model))
+	casecheck.Equal(t, "base", model.DB.DSN)
+	casecheck.Equal(t, config.Document{
+		"env": "prod",
+		"db":  map[string]interface{}{"dsn": "base", "pool": 10, "hosts": []interface{}{"c"}},
+	}, doc)
+}
+
+func TestUnit_ExpandFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "c.txt", "conf.d/1.yaml"} {
+		casecheck.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750))
+		casecheck.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("env: dev"), 0600))
+	}
+
+	files, err := config.ExpandFiles(filepath.Join(dir, "c.txt"), dir, filepath.Join(dir, "conf.d", "*.yaml"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, []string{
+		filepath.Join(dir, "c.txt"),
+		filepath.Join(dir, "a.yml"),
+		filepath.Join(dir, "b.yaml"),
+		filepath.Join(dir, "conf.d", "1.yaml"),
+	}, files)
+
+	_, err = config.ExpandFiles(filepath.Join(dir, "not-exist.yaml"))
+	casecheck.Error(t, err)
+
+	casecheck.Equal(t, "/etc/app/config.prod.yaml", config.ProfileFile("/etc/app/config.yaml", "PROD"))
+	casecheck.Equal(t, "", config.ProfileFile("/etc/app/config.yaml", ""))
+}
@@ -0,0 +1,136 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	tagSecret = "secret"
+	// SecretMask replacement of secret values in dumps
+	SecretMask = "******"
+
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Export merge config models into one document, fields with the `secret:"true"` tag are masked
+func Export(models ...interface{}) (Document, error) {
+	doc := Document{}
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		masked := maskCopy(reflect.ValueOf(model), false)
+		b, err := yaml.Marshal(masked.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("export config [%T]: %w", model, err)
+		}
+		data := make(map[string]interface{})
+		if err = yaml.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("export config [%T]: %w", model, err)
+		}
+		doc.Merge(data)
+	}
+	return doc, nil
+}
+
+// Dump write merged config models in yaml or json format with masked secrets
+func Dump(w io.Writer, format string, models ...interface{}) error {
+	doc, err := Export(models...)
+	if err != nil {
+		return err
+	}
+	return doc.Write(w, format)
+}
+
+// Write write the document in yaml or json format
+func (d Document) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}(d))
+	case FormatYAML, "yml", "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(map[string]interface{}(d)); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported dump format [%s]", format)
+	}
+}
+
+// nolint: gocyclo
+func maskCopy(rv reflect.Value, secret bool) reflect.Value {
+	if secret && !rv.IsZero() && rv.Kind() != reflect.Ptr && isScalar(rv.Type()) {
+		if rv.Kind() == reflect.String {
+			return reflect.ValueOf(SecretMask).Convert(rv.Type())
+		}
+		return reflect.Zero(rv.Type())
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv
+		}
+		result := reflect.New(rv.Type().Elem())
+		result.Elem().Set(maskCopy(rv.Elem(), secret))
+		return result
+
+	case reflect.Struct:
+		result := reflect.New(rv.Type()).Elem()
+		result.Set(rv)
+		_ = eachField(rv.Type(), func(f reflect.StructField, _ string, _ bool) error {
+			fieldSecret := secret || isSecret(f)
+			result.FieldByIndex(f.Index).Set(maskCopy(rv.FieldByIndex(f.Index), fieldSecret))
+			return nil
+		})
+		return result
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		result := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result.Index(i).Set(maskCopy(rv.Index(i), secret))
+		}
+		return result
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		result := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), maskCopy(iter.Value(), secret))
+		}
+		return result
+
+	default:
+		return rv
+	}
+}
+
+func isSecret(f reflect.StructField) bool {
+	value, ok := f.Tag.Lookup(tagSecret)
+	if !ok {
+		return false
+	}
+	return value == "" || strings.EqualFold(value, "true")
+}
@@ -0,0 +1,386 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// EnvName build the environment variable name from prefix and yaml path (MYAPP + log.level = MYAPP_LOG_LEVEL)
+func EnvName(prefix string, path ...string) string {
+	parts := make([]string, 0, len(path)+1)
+	if len(prefix) > 0 {
+		parts = append(parts, prefix)
+	}
+	parts = append(parts, path...)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Join(parts, "_"))
+}
+
+// ApplyEnv overrides values of the config model with environment variables.
+// Priority: defaults < config file < environment.
+// Items of maps with struct values are created from variables in form PREFIX_MAP_KEY_FIELD
+// (MYAPP_LISTENERS_ADMIN_ADDRESS creates the key admin), the new key is the part before
+// the first field name in lower case, so keys of new items can not contain names of the fields.
+func ApplyEnv(prefix string, target interface{}) error {
+	return ApplyEnvFrom(prefix, os.Environ(), target)
+}
+
+// ApplyEnvFrom same as ApplyEnv but reads variables from the list in KEY=VALUE form
+func ApplyEnvFrom(prefix string, environ []string, target interface{}) error {
+	if len(prefix) == 0 {
+		return nil
+	}
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config model must be a non-nil pointer, got [%T]", target)
+	}
+	src := newEnvSource(environ)
+	return src.apply(EnvName(prefix), rv.Elem())
+}
+
+type envSource struct {
+	data map[string]string
+	keys []string
+}
+
+func newEnvSource(environ []string) *envSource {
+	src := &envSource{data: make(map[string]string, len(environ))}
+	for _, line := range environ {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		src.data[key] = value
+		src.keys = append(src.keys, key)
+	}
+	sort.Strings(src.keys)
+	return src
+}
+
+func (v *envSource) lookup(name string) (string, bool) {
+	value, ok := v.data[name]
+	return value, ok
+}
+
+func (v *envSource) hasPrefix(name string) bool {
+	name += "_"
+	i := sort.SearchStrings(v.keys, name)
+	return i < len(v.keys) && strings.HasPrefix(v.keys[i], name)
+}
+
+func (v *envSource) withPrefix(name string) []string {
+	name += "_"
+	result := make([]string, 0, 2)
+	for i := sort.SearchStrings(v.keys, name); i < len(v.keys); i++ {
+		if !strings.HasPrefix(v.keys[i], name) {
+			break
+		}
+		result = append(result, v.keys[i])
+	}
+	return result
+}
+
+// nolint: gocyclo
+func (v *envSource) apply(name string, rv reflect.Value) error {
+	if value, ok := v.lookup(name); ok && isScalar(rv.Type()) {
+		return setValue(rv, value, name)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			if _, ok := v.lookup(name); !ok && !v.hasPrefix(name) {
+				return nil
+			}
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return v.apply(name, rv.Elem())
+
+	case reflect.Struct:
+		return eachField(rv.Type(), func(f reflect.StructField, key string, inline bool) error {
+			fv := rv.FieldByIndex(f.Index)
+			if inline {
+				return v.apply(name, fv)
+			}
+			return v.apply(EnvName(name, key), fv)
+		})
+
+	case reflect.Slice:
+		if value, ok := v.lookup(name); ok && isScalar(rv.Type().Elem()) {
+			return setSlice(rv, value, name)
+		}
+		for i := 0; ; i++ {
+			itemName := EnvName(name, strconv.Itoa(i))
+			_, ok := v.lookup(itemName)
+			if !ok && !v.hasPrefix(itemName) {
+				if i < rv.Len() {
+					continue
+				}
+				return nil
+			}
+			if i >= rv.Len() {
+				rv.Set(reflect.Append(rv, reflect.New(rv.Type().Elem()).Elem()))
+			}
+			if err := v.apply(itemName, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		if value, ok := v.lookup(name); ok && isScalar(rv.Type().Elem()) {
+			return setMap(rv, value, name)
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		if isScalar(rv.Type().Elem()) {
+			for _, envKey := range v.withPrefix(name) {
+				key := strings.ToLower(strings.TrimPrefix(envKey, name+"_"))
+				item := reflect.New(rv.Type().Elem()).Elem()
+				if err := setValue(item, v.data[envKey], envKey); err != nil {
+					return err
+				}
+				rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), item)
+			}
+			return nil
+		}
+		existing := make(map[string]bool)
+		for _, key := range rv.MapKeys() {
+			existing[EnvName(name, key.String())] = true
+			item := reflect.New(rv.Type().Elem()).Elem()
+			item.Set(rv.MapIndex(key))
+			if err := v.apply(EnvName(name, key.String()), item); err != nil {
+				return err
+			}
+			rv.SetMapIndex(key, item)
+		}
+		for _, key := range v.newMapKeys(name, rv.Type().Elem(), existing) {
+			item := reflect.New(rv.Type().Elem()).Elem()
+			if err := v.apply(EnvName(name, key), item); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), item)
+		}
+	}
+
+	return nil
+}
+
+// newMapKeys returns keys of the map items which are defined only by variables,
+// the key is the part of the name before the first field of the item
+func (v *envSource) newMapKeys(name string, rt reflect.Type, existing map[string]bool) []string {
+	fields := envFieldNames(rt)
+	if len(fields) == 0 {
+		return nil
+	}
+	found := make(map[string]bool)
+	result := make([]string, 0, 2)
+	for _, envKey := range v.withPrefix(name) {
+		rest := strings.TrimPrefix(envKey, name+"_")
+		for i := 1; i < len(rest); i++ {
+			if rest[i] != '_' || !hasEnvField(rest[i+1:], fields) {
+				continue
+			}
+			key := rest[:i]
+			if !existing[EnvName(name, key)] && !found[key] {
+				found[key] = true
+				result = append(result, strings.ToLower(key))
+			}
+			break
+		}
+	}
+	return result
+}
+
+// envFieldNames returns variable names of the fields of the struct, nil for other types
+func envFieldNames(rt reflect.Type) []string {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	result := make([]string, 0, rt.NumField())
+	_ = eachField(rt, func(f reflect.StructField, key string, inline bool) error { // nolint: errcheck
+		if inline {
+			result = append(result, envFieldNames(f.Type)...)
+			return nil
+		}
+		result = append(result, EnvName(key))
+		return nil
+	})
+	return result
+}
+
+func hasEnvField(name string, fields []string) bool {
+	for _, field := range fields {
+		if name == field || strings.HasPrefix(name, field+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// eachField call function for every exported field with the yaml key name
+func eachField(rt reflect.Type, call func(f reflect.StructField, key string, inline bool) error) error {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key, inline, skip := yamlKey(f)
+		if skip {
+			continue
+		}
+		if err := call(f, key, inline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func yamlKey(f reflect.StructField) (key string, inline, skip bool) {
+	tag := f.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "inline" {
+			return "", true, false
+		}
+	}
+	if len(name) == 0 {
+		name = strings.ToLower(f.Name)
+	}
+	return name, false, false
+}
+
+func isScalar(rt reflect.Type) bool {
+	if rt == durationType {
+		return true
+	}
+	switch rt.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Ptr:
+		return isScalar(rt.Elem())
+	default:
+		return false
+	}
+}
+
+// nolint: gocyclo
+func setValue(rv reflect.Value, value, name string) error {
+	if rv.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid duration in [%s]: %w", name, err)
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return setValue(rv.Elem(), value, name)
+	case reflect.String:
+		rv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid bool in [%s]: %w", name, err)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(value), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid int in [%s]: %w", name, err)
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(value), 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid uint in [%s]: %w", name, err)
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float in [%s]: %w", name, err)
+		}
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type [%s] in [%s]", rv.Type(), name)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	if len(strings.TrimSpace(value)) == 0 {
+		return nil
+	}
+	list := strings.Split(value, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+func setSlice(rv reflect.Value, value, name string) error {
+	list := splitList(value)
+	result := reflect.MakeSlice(rv.Type(), len(list), len(list))
+	for i, item := range list {
+		if err := setValue(result.Index(i), item, name); err != nil {
+			return err
+		}
+	}
+	rv.Set(result)
+	return nil
+}
+
+func setMap(rv reflect.Value, value, name string) error {
+	result := reflect.MakeMap(rv.Type())
+	for _, item := range splitList(value) {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid map item [%s] in [%s], want key=value", item, name)
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if err := setValue(elem, val, name); err != nil {
+			return err
+		}
+		result.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(rv.Type().Key()), elem)
+	}
+	rv.Set(result)
+	return nil
+}
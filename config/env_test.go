@@ -0,0 +1,96 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+type testEnvModel struct {
+	DB struct {
+		Primary struct {
+			DSN     string        `yaml:"dsn"`
+			Timeout time.Duration `yaml:"timeout"`
+		} `yaml:"primary"`
+		Replicas []testEnvReplica `yaml:"replicas"`
+	} `yaml:"db"`
+	Hosts  []string          `yaml:"hosts"`
+	Labels map[string]string `yaml:"labels"`
+	Limits map[string]int    `yaml:"limits"`
+	Debug  *bool             `yaml:"debug"`
+	Skip   string            `yaml:"-"`
+}
+
+type testEnvReplica struct {
+	DSN    string `yaml:"dsn"`
+	Weight int    `yaml:"weight"`
+}
+
+func TestUnit_ApplyEnvFrom(t *testing.T) {
+	model := &testEnvModel{}
+	model.DB.Primary.DSN = "from file"
+	model.DB.Replicas = []testEnvReplica{{DSN: "r0", Weight: 1}, {DSN: "r1", Weight: 1}}
+	model.Limits = map[string]int{"rps": 10}
+
+	environ := []string{
+		"MYAPP_DB_PRIMARY_DSN=postgres://env",
+		"MYAPP_DB_PRIMARY_TIMEOUT=3s",
+		"MYAPP_DB_REPLICAS_1_WEIGHT=5",
+		"MYAPP_DB_REPLICAS_2_DSN=r2",
+		"MYAPP_HOSTS=a.local, b.local",
+		"MYAPP_LABELS=team=core,zone=eu",
+		"MYAPP_LIMITS_BURST=20",
+		"MYAPP_DEBUG=true",
+		"MYAPP_SKIP=value",
+		"OTHER_DB_PRIMARY_DSN=other",
+	}
+	casecheck.NoError(t, config.ApplyEnvFrom("myapp", environ, model))
+
+	casecheck.Equal(t, "postgres://env", model.DB.Primary.DSN)
+	casecheck.Equal(t, 3*time.Second, model.DB.Primary.Timeout)
+	casecheck.Equal(t, []testEnvReplica{{DSN: "r0", Weight: 1}, {DSN: "r1", Weight: 5}, {DSN: "r2"}}, model.DB.Replicas)
+	casecheck.Equal(t, []string{"a.local", "b.local"}, model.Hosts)
+	casecheck.Equal(t, map[string]string{"team": "core", "zone": "eu"}, model.Labels)
+	casecheck.Equal(t, map[string]int{"rps": 10, "burst": 20}, model.Limits)
+	casecheck.True(t, model.Debug != nil && *model.Debug)
+	casecheck.Equal(t, "", model.Skip)
+}
+
+func TestUnit_ApplyEnvFromMapKeys(t *testing.T) {
+	model := &struct {
+		Pools map[string]testEnvReplica `yaml:"pools"`
+	}{Pools: map[string]testEnvReplica{"main": {DSN: "file", Weight: 1}}}
+
+	environ := []string{
+		"MYAPP_POOLS_MAIN_WEIGHT=2",
+		"MYAPP_POOLS_ADMIN_DSN=admin",
+		"MYAPP_POOLS_ADMIN_WEIGHT=3",
+		"MYAPP_POOLS_READ_ONLY_DSN=ro",
+		"MYAPP_POOLS_UNKNOWN=value",
+	}
+	casecheck.NoError(t, config.ApplyEnvFrom("myapp", environ, model))
+	casecheck.Equal(t, map[string]testEnvReplica{
+		"main":      {DSN: "file", Weight: 2},
+		"admin":     {DSN: "admin", Weight: 3},
+		"read_only": {DSN: "ro"},
+	}, model.Pools)
+}
+
+func TestUnit_ApplyEnvFromRootConfig(t *testing.T) {
+	conf := config.Default()
+	casecheck.NoError(t, config.ApplyEnvFrom("MYAPP", []string{"MYAPP_ENV=prod", "MYAPP_LOG_LEVEL=2"}, conf))
+	casecheck.Equal(t, "prod", conf.Env)
+	casecheck.Equal(t, uint32(2), conf.Log.Level)
+	casecheck.Equal(t, "/dev/stdout", conf.Log.FilePath)
+
+	casecheck.ErrorContains(t, config.ApplyEnvFrom("MYAPP", []string{"MYAPP_LOG_LEVEL=high"}, conf), "MYAPP_LOG_LEVEL")
+	casecheck.NoError(t, config.ApplyEnvFrom("", []string{"_ENV=x"}, conf))
+	casecheck.Equal(t, "prod", conf.Env)
+}
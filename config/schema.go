@@ -0,0 +1,277 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const tagDesc = "desc"
+
+const (
+	kindObject   = "object"
+	kindMap      = "map"
+	kindArray    = "array"
+	kindString   = "string"
+	kindInteger  = "integer"
+	kindNumber   = "number"
+	kindBoolean  = "boolean"
+	kindDuration = "duration"
+	kindAny      = "any"
+)
+
+type (
+	schemaNode struct {
+		Kind     string
+		Type     string
+		Desc     string
+		Default  interface{}
+		Required bool
+		Unsigned bool
+		Fields   []*schemaField
+		Elem     *schemaNode
+	}
+	schemaField struct {
+		Key  string
+		Node *schemaNode
+	}
+)
+
+// JSONSchema build JSON Schema (draft-07) of the config file from config models,
+// it can be used by editors for validation of yaml files
+func JSONSchema(models ...interface{}) ([]byte, error) {
+	root, err := buildSchema(models...)
+	if err != nil {
+		return nil, err
+	}
+	schema := root.jsonSchema()
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// Markdown build reference table of all config keys from config models
+func Markdown(models ...interface{}) ([]byte, error) {
+	root, err := buildSchema(models...)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("| Key | Type | Default | Required | Description |\n")
+	buf.WriteString("|-----|------|---------|----------|-------------|\n")
+	root.markdown(buf, "")
+	return buf.Bytes(), nil
+}
+
+func buildSchema(models ...interface{}) (*schemaNode, error) {
+	root := &schemaNode{Kind: kindObject, Type: kindObject}
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		rv := reflect.ValueOf(model)
+		cp := reflect.New(reflect.Indirect(rv).Type())
+		cp.Elem().Set(reflect.Indirect(rv))
+		if err := ApplyDefaults(cp.Interface()); err != nil {
+			return nil, err
+		}
+		node := newSchemaNode(cp.Elem(), make(map[reflect.Type]bool))
+		if node.Kind != kindObject {
+			return nil, fmt.Errorf("config model must be a struct, got [%T]", model)
+		}
+		root.merge(node)
+	}
+	return root, nil
+}
+
+// nolint: gocyclo
+func newSchemaNode(rv reflect.Value, visited map[reflect.Type]bool) *schemaNode {
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv = reflect.New(rt.Elem())
+		}
+		return newSchemaNode(rv.Elem(), visited)
+	}
+
+	node := &schemaNode{Type: rt.String()}
+	if rt == durationType {
+		node.Kind, node.Type = kindDuration, kindDuration
+		if !rv.IsZero() {
+			node.Default = rv.Interface().(fmt.Stringer).String()
+		}
+		return node
+	}
+	if isCustomUnmarshaler(rt) {
+		node.Kind = kindAny
+		return node
+	}
+
+	switch rt.Kind() {
+	case reflect.Struct:
+		node.Kind, node.Type = kindObject, kindObject
+		if visited[rt] {
+			node.Kind = kindAny
+			return node
+		}
+		visited[rt] = true
+		defer delete(visited, rt)
+
+		_ = eachField(rt, func(f reflect.StructField, key string, inline bool) error {
+			child := newSchemaNode(rv.FieldByIndex(f.Index), visited)
+			if inline {
+				node.merge(child)
+				return nil
+			}
+			child.Desc = f.Tag.Get(tagDesc)
+			child.Required = isRequired(f)
+			if isSecret(f) && child.Default != nil {
+				child.Default = SecretMask
+			}
+			node.Fields = append(node.Fields, &schemaField{Key: key, Node: child})
+			return nil
+		})
+		return node
+
+	case reflect.Slice, reflect.Array:
+		node.Kind = kindArray
+		node.Elem = newSchemaNode(newElem(rt.Elem()), visited)
+	case reflect.Map:
+		node.Kind = kindMap
+		node.Elem = newSchemaNode(newElem(rt.Elem()), visited)
+	case reflect.String:
+		node.Kind = kindString
+	case reflect.Bool:
+		node.Kind = kindBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		node.Kind = kindInteger
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		node.Kind, node.Unsigned = kindInteger, true
+	case reflect.Float32, reflect.Float64:
+		node.Kind = kindNumber
+	default:
+		node.Kind = kindAny
+		return node
+	}
+
+	switch {
+	case node.Kind == kindArray || node.Kind == kindMap:
+		if rv.Len() > 0 {
+			node.Default = rv.Interface()
+		}
+	case !rv.IsZero():
+		node.Default = rv.Interface()
+	}
+	return node
+}
+
+func newElem(rt reflect.Type) reflect.Value {
+	rv := reflect.New(rt)
+	_ = applyDefaults("", rv.Elem())
+	return rv.Elem()
+}
+
+func (n *schemaNode) merge(src *schemaNode) {
+	for _, sf := range src.Fields {
+		found := false
+		for _, f := range n.Fields {
+			if f.Key != sf.Key {
+				continue
+			}
+			found = true
+			if f.Node.Kind == kindObject && sf.Node.Kind == kindObject {
+				f.Node.merge(sf.Node)
+			}
+			break
+		}
+		if !found {
+			n.Fields = append(n.Fields, sf)
+		}
+	}
+}
+
+func (n *schemaNode) jsonSchema() map[string]interface{} {
+	result := make(map[string]interface{})
+	if len(n.Desc) > 0 {
+		result["description"] = n.Desc
+	}
+	if n.Default != nil {
+		result["default"] = n.Default
+	}
+
+	switch n.Kind {
+	case kindObject:
+		result["type"] = "object"
+		props := make(map[string]interface{}, len(n.Fields))
+		required := make([]string, 0)
+		for _, f := range n.Fields {
+			props[f.Key] = f.Node.jsonSchema()
+			if f.Node.Required {
+				required = append(required, f.Key)
+			}
+		}
+		result["properties"] = props
+		result["additionalProperties"] = false
+		if len(required) > 0 {
+			result["required"] = required
+		}
+	case kindMap:
+		result["type"] = "object"
+		result["additionalProperties"] = n.Elem.jsonSchema()
+	case kindArray:
+		result["type"] = "array"
+		result["items"] = n.Elem.jsonSchema()
+	case kindDuration:
+		result["type"] = "string"
+		result["pattern"] = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	case kindInteger:
+		result["type"] = "integer"
+		if n.Unsigned {
+			result["minimum"] = 0
+		}
+	case kindString, kindNumber, kindBoolean:
+		result["type"] = n.Kind
+	}
+	return result
+}
+
+func (n *schemaNode) markdown(buf *bytes.Buffer, path string) {
+	switch n.Kind {
+	case kindObject:
+		for _, f := range n.Fields {
+			f.Node.markdown(buf, joinPath(path, f.Key, false))
+		}
+		return
+	case kindArray:
+		if n.Elem.Kind == kindObject {
+			n.Elem.markdown(buf, path+"[]")
+			return
+		}
+	case kindMap:
+		if n.Elem.Kind == kindObject {
+			n.Elem.markdown(buf, joinPath(path, "<key>", false))
+			return
+		}
+	}
+
+	def := ""
+	if n.Default != nil {
+		def = "`" + fmt.Sprintf("%v", n.Default) + "`"
+	}
+	required := ""
+	if n.Required {
+		required = "yes"
+	}
+	fmt.Fprintf(buf, "| `%s` | %s | %s | %s | %s |\n",
+		path, escapeMarkdown(n.Type), def, required, escapeMarkdown(n.Desc))
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
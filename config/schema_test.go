@@ -0,0 +1,90 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+type testSchemaModel struct {
+	HTTP struct {
+		Addr    string        `yaml:"addr" default:"0.0.0.0:8080" desc:"listen address"`
+		Timeout time.Duration `yaml:"timeout" default:"5s"`
+	} `yaml:"http"`
+	Workers []struct {
+		Name string `yaml:"name" required:"true" desc:"worker | name"`
+	} `yaml:"workers"`
+	Limits map[string]uint `yaml:"limits"`
+}
+
+func TestUnit_JSONSchema(t *testing.T) {
+	b, err := config.JSONSchema(config.Default(), &testSchemaModel{})
+	casecheck.NoError(t, err)
+
+	var schema map[string]interface{}
+	casecheck.NoError(t, json.Unmarshal(b, &schema))
+	casecheck.Equal(t, "http://json-schema.org/draft-07/schema#", schema["$schema"])
+
+	props := schema["properties"].(map[string]interface{})
+	casecheck.Equal(t, map[string]interface{}{"type": "string", "default": "DEV"}, props["env"])
+
+	http := props["http"].(map[string]interface{})["properties"].(map[string]interface{})
+	casecheck.Equal(t, map[string]interface{}{
+		"type": "string", "default": "0.0.0.0:8080", "description": "listen address",
+	}, http["addr"])
+	casecheck.Equal(t, "5s", http["timeout"].(map[string]interface{})["default"])
+
+	workers := props["workers"].(map[string]interface{})["items"].(map[string]interface{})
+	casecheck.Equal(t, []interface{}{"name"}, workers["required"])
+
+	limits := props["limits"].(map[string]interface{})
+	casecheck.Equal(t, map[string]interface{}{"type": "integer", "minimum": float64(0)}, limits["additionalProperties"])
+}
+
+func TestUnit_Markdown(t *testing.T) {
+	b, err := config.Markdown(config.Default(), &testSchemaModel{})
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "| Key | Type | Default | Required | Description |\n"+
+		"|-----|------|---------|----------|-------------|\n"+
+		"| `env` | string | `DEV` |  |  |\n"+
+		"| `log.level` | uint32 | `4` |  |  |\n"+
+		"| `log.file_path` | string | `/dev/stdout` |  |  |\n"+
+		"| `log.format` | string | `auto` |  |  |\n"+
+		"| `log.rotate.max_size` | int64 |  |  |  |\n"+
+		"| `log.rotate.interval` | duration |  |  |  |\n"+
+		"| `log.rotate.max_backups` | int |  |  |  |\n"+
+		"| `log.rotate.compress` | bool |  |  |  |\n"+
+		"| `log.syslog.facility` | string |  |  |  |\n"+
+		"| `log.syslog.buffer` | int |  |  |  |\n"+
+		"| `log.syslog.timeout` | duration |  |  |  |\n"+
+		"| `log.syslog.severities` | map[string]string |  |  |  |\n"+
+		"| `log.sinks[].level` | uint32 |  |  |  |\n"+
+		"| `log.sinks[].file_path` | string |  |  |  |\n"+
+		"| `log.sinks[].format` | string |  |  |  |\n"+
+		"| `log.sinks[].rotate.max_size` | int64 |  |  |  |\n"+
+		"| `log.sinks[].rotate.interval` | duration |  |  |  |\n"+
+		"| `log.sinks[].rotate.max_backups` | int |  |  |  |\n"+
+		"| `log.sinks[].rotate.compress` | bool |  |  |  |\n"+
+		"| `log.sinks[].syslog.facility` | string |  |  |  |\n"+
+		"| `log.sinks[].syslog.buffer` | int |  |  |  |\n"+
+		"| `log.sinks[].syslog.timeout` | duration |  |  |  |\n"+
+		"| `log.sinks[].syslog.severities` | map[string]string |  |  |  |\n"+
+		"| `log.modules` | map[string]uint32 |  |  |  |\n"+
+		"| `log.async.enabled` | bool |  |  |  |\n"+
+		"| `log.async.queue` | int |  |  |  |\n"+
+		"| `log.async.policy` | string |  |  |  |\n"+
+		"| `listeners.<key>.network` | string |  |  |  |\n"+
+		"| `listeners.<key>.address` | string |  | yes |  |\n"+
+		"| `http.addr` | string | `0.0.0.0:8080` |  | listen address |\n"+
+		"| `http.timeout` | duration | `5s` |  |  |\n"+
+		"| `workers[].name` | string |  | yes | worker \\| name |\n"+
+		"| `limits` | map[string]uint |  |  |  |\n", string(b))
+}
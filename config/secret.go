@@ -0,0 +1,232 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// SecretFile reference to file content: file:///run/secrets/db_pass
+	SecretFile = "file://"
+	// SecretEnv reference to environment variable: env://DB_PASS
+	SecretEnv = "env://"
+	// SecretEncrypted value encrypted with the local key file: enc://<base64>
+	SecretEncrypted = "enc://"
+	// SecretRaw escape of the value which looks like reference: raw://file:///var/data is file:///var/data
+	SecretRaw = "raw://"
+
+	secretKeySize = 32
+)
+
+// Secrets resolver of secret references in fields of config models tagged with `secret:"true"`.
+// References are resolved after decoding of the config files and the env overlay, not in the documents
+// before decoding, so values of other fields which look like references (URLs) are never changed
+type Secrets struct {
+	keyFile string
+	key     []byte
+	mux     sync.Mutex
+}
+
+// NewSecrets create resolver of secret references, the key file is used for enc:// values
+func NewSecrets(keyFile string) *Secrets {
+	return &Secrets{
+		keyFile: keyFile,
+	}
+}
+
+// Resolve replace secret references in string values of the fields tagged with `secret:"true"`,
+// values of other fields are not changed, the model must be a non-nil pointer
+func (v *Secrets) Resolve(model interface{}) error {
+	rv := reflect.ValueOf(model)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config model must be a non-nil pointer, got [%T]", model)
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	return v.resolveValue("", rv.Elem(), false)
+}
+
+// nolint: gocyclo
+func (v *Secrets) resolveValue(path string, rv reflect.Value, secret bool) error {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return v.resolveValue(path, rv.Elem(), secret)
+
+	case reflect.Interface:
+		if rv.IsNil() || !secret {
+			return nil
+		}
+		item := reflect.New(rv.Elem().Type()).Elem()
+		item.Set(rv.Elem())
+		if err := v.resolveValue(path, item, secret); err != nil {
+			return err
+		}
+		rv.Set(item)
+
+	case reflect.Struct:
+		return eachField(rv.Type(), func(f reflect.StructField, key string, inline bool) error {
+			return v.resolveValue(joinPath(path, key, inline), rv.FieldByIndex(f.Index), secret || isSecret(f))
+		})
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := v.resolveValue(path+"["+strconv.Itoa(i)+"]", rv.Index(i), secret); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		for _, key := range rv.MapKeys() {
+			item := reflect.New(rv.Type().Elem()).Elem()
+			item.Set(rv.MapIndex(key))
+			if err := v.resolveValue(joinPath(path, fmt.Sprint(key.Interface()), false), item, secret); err != nil {
+				return err
+			}
+			rv.SetMapIndex(key, item)
+		}
+
+	case reflect.String:
+		if !secret {
+			return nil
+		}
+		value, err := v.lookup(rv.String())
+		if err != nil {
+			return fmt.Errorf("resolve secret [%s]: %w", path, err)
+		}
+		rv.SetString(value)
+	}
+	return nil
+}
+
+// lookup returns value of the reference, values without reference prefix are returned as is
+func (v *Secrets) lookup(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretRaw):
+		return strings.TrimPrefix(value, SecretRaw), nil
+
+	case strings.HasPrefix(value, SecretFile):
+		b, err := os.ReadFile(strings.TrimPrefix(value, SecretFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+
+	case strings.HasPrefix(value, SecretEnv):
+		name := strings.TrimPrefix(value, SecretEnv)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable [%s] is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, SecretEncrypted):
+		if v.key == nil {
+			key, err := ReadSecretKey(v.keyFile)
+			if err != nil {
+				return "", err
+			}
+			v.key = key
+		}
+		return Decrypt(v.key, value)
+
+	default:
+		return value, nil
+	}
+}
+
+// ReadSecretKey read 32 bytes key for AES-256 from file in raw, hex or base64 form
+func ReadSecretKey(filename string) ([]byte, error) {
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("secret key file is not set")
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read secret key file: %w", err)
+	}
+	if len(b) == secretKeySize {
+		return b, nil
+	}
+	text := strings.TrimSpace(string(b))
+	if key, err0 := hex.DecodeString(text); err0 == nil && len(key) == secretKeySize {
+		return key, nil
+	}
+	if key, err0 := base64.StdEncoding.DecodeString(text); err0 == nil && len(key) == secretKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("secret key must be %d bytes in raw, hex or base64 form", secretKeySize)
+}
+
+// GenerateSecretKey create random key for AES-256 in hex form
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, secretKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Encrypt encrypt value with AES-256-GCM, result can be used in config as enc://... reference
+func Encrypt(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := aead.Seal(nonce, nonce, []byte(value), nil)
+	return SecretEncrypted + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt decrypt enc://... reference with AES-256-GCM
+func Decrypt(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, SecretEncrypted))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	nonce, data := data[:aead.NonceSize()], data[aead.NonceSize():]
+	result, err := aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt value: %w", err)
+	}
+	return string(result), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != secretKeySize {
+		return nil, fmt.Errorf("secret key must be %d bytes", secretKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
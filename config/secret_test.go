@@ -0,0 +1,80 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+func TestUnit_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	keyHex, err := config.GenerateSecretKey()
+	casecheck.NoError(t, err)
+	keyFile := filepath.Join(dir, "key")
+	casecheck.NoError(t, os.WriteFile(keyFile, []byte(keyHex+"\n"), 0600))
+	passFile := filepath.Join(dir, "db_pass")
+	casecheck.NoError(t, os.WriteFile(passFile, []byte("file-pass\n"), 0600))
+	t.Setenv("TEST_SECRET_TOKEN", "env-token")
+
+	key, err := hex.DecodeString(keyHex)
+	casecheck.NoError(t, err)
+	encrypted, err := config.Encrypt(key, "enc-value")
+	casecheck.NoError(t, err)
+
+	type Replica struct {
+		Token string `yaml:"token" secret:"true"`
+		URL   string `yaml:"url"`
+	}
+	type Model struct {
+		DB struct {
+			Password string            `yaml:"password" secret:"true"`
+			Data     string            `yaml:"data"`
+			Escaped  string            `yaml:"escaped" secret:"true"`
+			Replicas []Replica         `yaml:"replicas"`
+			Tokens   map[string]string `yaml:"tokens" secret:"true"`
+		} `yaml:"db"`
+		APIKey string `yaml:"api_key" secret:""`
+	}
+	model := &Model{}
+	model.DB.Password = "file://" + passFile
+	model.DB.Data = "file:///var/data"
+	model.DB.Escaped = "raw://file:///var/data"
+	model.DB.Replicas = []Replica{{Token: "env://TEST_SECRET_TOKEN", URL: "env://TEST_SECRET_TOKEN"}}
+	model.DB.Tokens = map[string]string{"a": "env://TEST_SECRET_TOKEN", "b": "plain"}
+	model.APIKey = encrypted
+
+	secrets := config.NewSecrets(keyFile)
+	casecheck.NoError(t, secrets.Resolve(model))
+	casecheck.Equal(t, "file-pass", model.DB.Password)
+	casecheck.Equal(t, "file:///var/data", model.DB.Data)
+	casecheck.Equal(t, "file:///var/data", model.DB.Escaped)
+	casecheck.Equal(t, "env-token", model.DB.Replicas[0].Token)
+	casecheck.Equal(t, "env://TEST_SECRET_TOKEN", model.DB.Replicas[0].URL)
+	casecheck.Equal(t, map[string]string{"a": "env-token", "b": "plain"}, model.DB.Tokens)
+	casecheck.Equal(t, "enc-value", model.APIKey)
+
+	// values of the env overlay are resolved the same way
+	t.Setenv("TEST_SECRET_APP_DB_PASSWORD", "env://TEST_SECRET_TOKEN")
+	model = &Model{}
+	casecheck.NoError(t, config.ApplyEnv("TEST_SECRET_APP", model))
+	casecheck.NoError(t, secrets.Resolve(model))
+	casecheck.Equal(t, "env-token", model.DB.Password)
+
+	type Secret struct {
+		Value string `yaml:"value" secret:"true"`
+	}
+	err = config.NewSecrets("").Resolve(&Secret{Value: encrypted})
+	casecheck.ErrorContains(t, err, "resolve secret [value]: secret key file is not set")
+	err = config.NewSecrets("").Resolve(&Secret{Value: "env://TEST_SECRET_NOT_EXIST"})
+	casecheck.ErrorContains(t, err, "TEST_SECRET_NOT_EXIST")
+	casecheck.Error(t, config.NewSecrets("").Resolve(Secret{}))
+}
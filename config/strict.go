@@ -0,0 +1,153 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// StrictMode behavior for keys of the config file which are not used by any model
+type StrictMode uint8
+
+const (
+	// StrictOff unknown keys are ignored
+	StrictOff StrictMode = iota
+	// StrictWarn unknown keys are written to the log
+	StrictWarn
+	// StrictFail unknown keys stop the application
+	StrictFail
+)
+
+type keyNode struct {
+	any      bool
+	children map[string]*keyNode
+	elem     *keyNode
+}
+
+func newKeyNode() *keyNode {
+	return &keyNode{children: make(map[string]*keyNode)}
+}
+
+// UnknownKeys returns dotted paths of all document keys which are not consumed by any of the models
+func UnknownKeys(doc map[string]interface{}, models ...interface{}) []string {
+	root := newKeyNode()
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		root.addType(reflect.TypeOf(model), make(map[reflect.Type]bool))
+	}
+	result := make([]string, 0)
+	root.check("", doc, &result)
+	sort.Strings(result)
+	return result
+}
+
+// nolint: gocyclo
+func (n *keyNode) addType(rt reflect.Type, visited map[reflect.Type]bool) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if isCustomUnmarshaler(rt) {
+		n.any = true
+		return
+	}
+
+	switch rt.Kind() {
+	case reflect.Struct:
+		if visited[rt] {
+			n.any = true
+			return
+		}
+		visited[rt] = true
+		defer delete(visited, rt)
+
+		_ = eachField(rt, func(f reflect.StructField, key string, inline bool) error {
+			if inline {
+				n.addType(f.Type, visited)
+				return nil
+			}
+			child, ok := n.children[key]
+			if !ok {
+				child = newKeyNode()
+				n.children[key] = child
+			}
+			child.addType(f.Type, visited)
+			return nil
+		})
+
+	case reflect.Map:
+		if rt.Elem().Kind() == reflect.Interface {
+			n.any = true
+			return
+		}
+		if n.elem == nil {
+			n.elem = newKeyNode()
+		}
+		n.elem.addType(rt.Elem(), visited)
+
+	case reflect.Slice, reflect.Array:
+		if n.elem == nil {
+			n.elem = newKeyNode()
+		}
+		n.elem.addType(rt.Elem(), visited)
+
+	case reflect.Interface:
+		n.any = true
+	}
+}
+
+func (n *keyNode) check(path string, value interface{}, result *[]string) {
+	if n.any {
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			n.checkKey(path, key, item, result)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			n.checkKey(path, fmt.Sprintf("%v", key), item, result)
+		}
+	case []interface{}:
+		if n.elem == nil {
+			return
+		}
+		for i, item := range v {
+			n.elem.check(fmt.Sprintf("%s[%d]", path, i), item, result)
+		}
+	}
+}
+
+func (n *keyNode) checkKey(path, key string, value interface{}, result *[]string) {
+	itemPath := joinPath(path, key, false)
+	if child, ok := n.children[key]; ok {
+		child.check(itemPath, value, result)
+		return
+	}
+	if n.elem != nil && len(n.children) == 0 {
+		n.elem.check(itemPath, value, result)
+		return
+	}
+	*result = append(*result, itemPath)
+}
+
+func isCustomUnmarshaler(rt reflect.Type) bool {
+	if rt == durationType {
+		return false
+	}
+	pt := reflect.PointerTo(rt)
+	for _, name := range []string{"UnmarshalYAML", "UnmarshalText"} {
+		if _, ok := pt.MethodByName(name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+type testStrictModel struct {
+	DB struct {
+		DSN      string            `yaml:"dsn"`
+		Replicas []testEnvReplica  `yaml:"replicas"`
+		Options  map[string]string `yaml:"options"`
+	} `yaml:"db"`
+	Extra map[string]interface{} `yaml:"extra"`
+}
+
+func TestUnit_UnknownKeys(t *testing.T) {
+	doc := map[string]interface{}{
+		"env": "dev",
+		"log": map[string]interface{}{
+			"level":    4,
+			"file_pat": "/dev/stdout",
+		},
+		"db": map[string]interface{}{
+			"dsn": "postgres://",
+			"replicas": []interface{}{
+				map[string]interface{}{"dsn": "r0", "wieght": 1},
+			},
+			"options": map[string]interface{}{"sslmode": "disable"},
+		},
+		"extra": map[string]interface{}{"any": map[string]interface{}{"thing": 1}},
+		"cache": map[string]interface{}{"size": 1},
+	}
+
+	keys := config.UnknownKeys(doc, &config.Config{}, testStrictModel{})
+	casecheck.Equal(t, []string{"cache", "db.replicas[0].wieght", "log.file_pat"}, keys)
+}
@@ -0,0 +1,167 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.osspkg.com/errors"
+)
+
+type (
+	// Defaulter config model which sets own default values, called before decoding
+	Defaulter interface {
+		Default()
+	}
+	// Validator config model which checks own values, called after decoding
+	Validator interface {
+		Validate() error
+	}
+)
+
+const (
+	tagDefault  = "default"
+	tagRequired = "required"
+)
+
+// ApplyDefaults fill empty fields from the `default:"..."` tag and call Default() of models
+func ApplyDefaults(target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config model must be a non-nil pointer, got [%T]", target)
+	}
+	return applyDefaults("", rv.Elem())
+}
+
+func applyDefaults(path string, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return applyDefaults(path, rv.Elem())
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	err := eachField(rv.Type(), func(f reflect.StructField, key string, inline bool) error {
+		fv := rv.FieldByIndex(f.Index)
+		fp := joinPath(path, key, inline)
+		if value, ok := f.Tag.Lookup(tagDefault); ok && fv.IsZero() {
+			if err := setDefault(fv, value, fp); err != nil {
+				return err
+			}
+		}
+		return applyDefaults(fp, fv)
+	})
+	if err != nil {
+		return err
+	}
+
+	if d, ok := rv.Addr().Interface().(Defaulter); ok {
+		d.Default()
+	}
+	return nil
+}
+
+func setDefault(rv reflect.Value, value, path string) error {
+	switch rv.Kind() {
+	case reflect.Slice:
+		return setSlice(rv, value, path)
+	case reflect.Map:
+		return setMap(rv, value, path)
+	default:
+		return setValue(rv, value, path)
+	}
+}
+
+// Validate check fields with the `required:"true"` tag and call Validate() of models,
+// all found errors are returned together
+func Validate(target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config model must be a non-nil pointer, got [%T]", target)
+	}
+	var result error
+	validate("", rv.Elem(), func(err error) {
+		result = errors.Wrap(result, err)
+	})
+	return result
+}
+
+func validate(path string, rv reflect.Value, report func(err error)) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			validate(path, rv.Elem(), report)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			validate(fmt.Sprintf("%s[%d]", path, i), rv.Index(i), report)
+		}
+		return
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+		for _, key := range keys {
+			// copy of the value is addressable for Validator
+			item := reflect.New(rv.Type().Elem()).Elem()
+			item.Set(rv.MapIndex(key))
+			validate(joinPath(path, fmt.Sprint(key.Interface()), false), item, report)
+		}
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+
+	_ = eachField(rv.Type(), func(f reflect.StructField, key string, inline bool) error {
+		fv := rv.FieldByIndex(f.Index)
+		fp := joinPath(path, key, inline)
+		if isRequired(f) && fv.IsZero() {
+			report(fmt.Errorf("config field [%s] is required", fp))
+			return nil
+		}
+		validate(fp, fv, report)
+		return nil
+	})
+
+	if !rv.CanAddr() {
+		return
+	}
+	if v, ok := rv.Addr().Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			if len(path) == 0 {
+				path = rv.Type().String()
+			}
+			report(fmt.Errorf("config [%s] is invalid: %w", path, err))
+		}
+	}
+}
+
+func isRequired(f reflect.StructField) bool {
+	value, ok := f.Tag.Lookup(tagRequired)
+	if !ok {
+		return false
+	}
+	return value == "" || strings.EqualFold(value, "true")
+}
+
+func joinPath(path, key string, inline bool) string {
+	switch {
+	case inline:
+		return path
+	case len(path) == 0:
+		return key
+	default:
+		return path + "." + key
+	}
+}
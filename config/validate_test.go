@@ -0,0 +1,73 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+type testValidateModel struct {
+	HTTP struct {
+		Addr    string        `yaml:"addr" default:"0.0.0.0:8080"`
+		Timeout time.Duration `yaml:"timeout" default:"5s"`
+		Methods []string      `yaml:"methods" default:"GET,POST"`
+	} `yaml:"http"`
+	DB struct {
+		DSN      string `yaml:"dsn" required:"true"`
+		MaxConns int    `yaml:"max_conns"`
+	} `yaml:"db"`
+	Workers []testValidateWorker          `yaml:"workers"`
+	Pools   map[string]testValidateWorker `yaml:"pools"`
+}
+
+func (v *testValidateModel) Default() {
+	v.DB.MaxConns = 10
+}
+
+func (v *testValidateModel) Validate() error {
+	if v.DB.MaxConns <= 0 {
+		return fmt.Errorf("db.max_conns must be positive")
+	}
+	return nil
+}
+
+type testValidateWorker struct {
+	Name string `yaml:"name" required:""`
+}
+
+func TestUnit_ApplyDefaults(t *testing.T) {
+	model := &testValidateModel{}
+	model.HTTP.Addr = "127.0.0.1:80"
+
+	casecheck.NoError(t, config.ApplyDefaults(model))
+	casecheck.Equal(t, "127.0.0.1:80", model.HTTP.Addr)
+	casecheck.Equal(t, 5*time.Second, model.HTTP.Timeout)
+	casecheck.Equal(t, []string{"GET", "POST"}, model.HTTP.Methods)
+	casecheck.Equal(t, 10, model.DB.MaxConns)
+}
+
+func TestUnit_Validate(t *testing.T) {
+	model := &testValidateModel{}
+	model.Workers = []testValidateWorker{{Name: "a"}, {}}
+	model.Pools = map[string]testValidateWorker{"main": {}}
+
+	err := config.Validate(model)
+	casecheck.ErrorContains(t, err, "config field [pools.main.name] is required")
+	casecheck.ErrorContains(t, err, "config field [db.dsn] is required")
+	casecheck.ErrorContains(t, err, "config field [workers[1].name] is required")
+	casecheck.ErrorContains(t, err, "db.max_conns must be positive")
+
+	model.DB.DSN = "postgres://"
+	model.DB.MaxConns = 1
+	model.Workers[1].Name = "b"
+	model.Pools["main"] = testValidateWorker{Name: "c"}
+	casecheck.NoError(t, config.Validate(model))
+}
@@ -0,0 +1,95 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go.osspkg.com/errors"
+)
+
+type (
+	// Watcher subscription to reloaded config models
+	Watcher interface {
+		// OnReload register a function which arguments are config models,
+		// for example func(c *MyConfig, root *config.Config) error
+		OnReload(call interface{}) error
+	}
+
+	// WatcherNotifier watcher which sends new config models to subscribers
+	WatcherNotifier interface {
+		Watcher
+		Notify(configs ...interface{}) error
+	}
+
+	_watcher struct {
+		calls []reflect.Value
+		mux   sync.RWMutex
+	}
+)
+
+var errType = reflect.TypeOf(new(error)).Elem()
+
+// NewWatcher create watcher of config reloads
+func NewWatcher() WatcherNotifier {
+	return &_watcher{
+		calls: make([]reflect.Value, 0, 2),
+	}
+}
+
+func (v *_watcher) OnReload(call interface{}) error {
+	ref := reflect.TypeOf(call)
+	if ref == nil || ref.Kind() != reflect.Func {
+		return fmt.Errorf("reload subscriber must be a function, got [%T]", call)
+	}
+	for i := 0; i < ref.NumOut(); i++ {
+		if ref.Out(i) != errType {
+			return fmt.Errorf("reload subscriber can return only error, got [%s]", ref)
+		}
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.calls = append(v.calls, reflect.ValueOf(call))
+	return nil
+}
+
+// Notify call all subscribers which arguments are found in configs,
+// subscribers with unknown arguments are skipped
+func (v *_watcher) Notify(configs ...interface{}) error {
+	index := make(map[reflect.Type]reflect.Value, len(configs))
+	for _, c := range configs {
+		index[reflect.TypeOf(c)] = reflect.ValueOf(c)
+	}
+
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	var result error
+	for _, call := range v.calls {
+		ref := call.Type()
+		args := make([]reflect.Value, 0, ref.NumIn())
+		for i := 0; i < ref.NumIn(); i++ {
+			arg, ok := index[ref.In(i)]
+			if !ok {
+				break
+			}
+			args = append(args, arg)
+		}
+		if len(args) != ref.NumIn() {
+			continue
+		}
+		for _, out := range call.Call(args) {
+			if err, ok := out.Interface().(error); ok && err != nil {
+				result = errors.Wrap(result, err)
+			}
+		}
+	}
+	return result
+}
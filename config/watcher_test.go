@@ -0,0 +1,43 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+func TestUnit_Watcher(t *testing.T) {
+	w := config.NewWatcher()
+
+	var (
+		level uint32
+		dsn   string
+		calls int
+	)
+	casecheck.NoError(t, w.OnReload(func(c *config.Config) { level = c.Log.Level }))
+	casecheck.NoError(t, w.OnReload(func(c *testStrictModel, _ *config.Config) error {
+		dsn = c.DB.DSN
+		return nil
+	}))
+	casecheck.NoError(t, w.OnReload(func(_ *testEnvModel) { calls++ }))
+	casecheck.NoError(t, w.OnReload(func(_ *config.Config) error { return fmt.Errorf("reload fail") }))
+	casecheck.Error(t, w.OnReload("func"))
+	casecheck.Error(t, w.OnReload(func() int { return 0 }))
+
+	root := config.Default()
+	root.Log.Level = 1
+	model := &testStrictModel{}
+	model.DB.DSN = "new"
+
+	casecheck.ErrorContains(t, w.Notify(root, model), "reload fail")
+	casecheck.Equal(t, uint32(1), level)
+	casecheck.Equal(t, "new", dsn)
+	casecheck.Equal(t, 0, calls)
+}
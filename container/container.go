@@ -8,22 +8,33 @@ package container
 import (
 	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
 
 	"go.osspkg.com/algorithms/graph/kahn"
 	"go.osspkg.com/errors"
 	"go.osspkg.com/grape/errs"
+	"go.osspkg.com/grape/lifecycle"
 	reflect2 "go.osspkg.com/grape/reflect"
 	"go.osspkg.com/grape/services"
 	"go.osspkg.com/syncing"
 	"go.osspkg.com/xc"
 )
 
+// StageConstructor stage of calling constructors of dependencies
+const StageConstructor = "constructor"
+
 type (
 	_container struct {
-		kahn   *kahn.Graph
-		srv    services.TServices
-		store  *objectStorage
-		status syncing.Switch
+		kahn     *kahn.Graph
+		srv      services.TServices
+		store    *objectStorage
+		status   syncing.Switch
+		timings  []services.Timing
+		observer lifecycle.Observer
+		mux      sync.Mutex
 	}
 
 	TContainer interface {
@@ -32,6 +43,20 @@ type (
 		Invoke(item interface{}) error
 		BreakPoint(item interface{}) error
 		Stop() error
+		Stopping() []string
+		States() []services.State
+		Objects() []Object
+		Timings() []services.Timing
+		Observe(o lifecycle.Observer)
+	}
+
+	// Object dependency registered in the container
+	Object struct {
+		Address string
+		// Initiated the object is created or registered as value
+		Initiated bool
+		// Service the object is a service
+		Service bool
 	}
 )
 
@@ -52,6 +77,46 @@ func (v *_container) Stop() error {
 	return v.srv.Down()
 }
 
+// Stopping - services which are not stopped yet
+func (v *_container) Stopping() []string {
+	return v.srv.Stopping()
+}
+
+// States - lifecycle states of services
+func (v *_container) States() []services.State {
+	return v.srv.States()
+}
+
+// Observe - set observer of lifecycle events of dependencies and services, it is set before registering
+func (v *_container) Observe(o lifecycle.Observer) {
+	v.observer = o
+	v.srv.Observe(o)
+}
+
+// Timings - durations of constructor calls and Up/Down of services in order of calling
+func (v *_container) Timings() []services.Timing {
+	v.mux.Lock()
+	result := make([]services.Timing, 0, len(v.timings))
+	result = append(result, v.timings...)
+	v.mux.Unlock()
+	return append(result, v.srv.Timings()...)
+}
+
+// Objects - all dependencies sorted by address
+func (v *_container) Objects() []Object {
+	result := make([]Object, 0)
+	_ = v.store.Each(func(item *objectStorageItem) error { // nolint: errcheck
+		result = append(result, Object{
+			Address:   item.Address,
+			Initiated: item.RelationType == asTypeExist,
+			Service:   item.Service != itNotService,
+		})
+		return nil
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
 // Start - initialize dependencies and start
 func (v *_container) Start() error {
 	if !v.status.On() {
@@ -85,6 +150,11 @@ func (v *_container) Register(items ...interface{}) error {
 		if err := v.store.Add(ref, item, rt); err != nil {
 			return err
 		}
+		if v.observer != nil {
+			if si, err := v.store.GetByReflect(ref, item); err == nil {
+				lifecycle.Emit(v.observer, lifecycle.ProviderRegistered, constructorName(si), time.Time{}, nil)
+			}
+		}
 	}
 	return nil
 }
@@ -259,7 +329,11 @@ func (v *_container) run() error {
 			delete(names, name)
 			continue
 		}
+		started := time.Now()
+		lifecycle.Emit(v.observer, lifecycle.ConstructorStarted, constructorName(item), time.Time{}, nil)
 		_, args, err := v.callArgs(item)
+		v.addTiming(constructorName(item), started, err)
+		lifecycle.Emit(v.observer, lifecycle.ConstructorFinished, constructorName(item), started, err)
 		if err != nil {
 			return errors.Wrapf(err, "initialize error [%s]", name)
 		}
@@ -286,3 +360,23 @@ func (v *_container) run() error {
 
 	return nil
 }
+
+func (v *_container) addTiming(name string, started time.Time, err error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.timings = append(v.timings, services.Timing{
+		Stage: StageConstructor, Name: name, Duration: time.Since(started), Err: err,
+	})
+}
+
+// constructorName returns name with signature of the function or address of the struct,
+// signature distinguishes anonymous functions
+func constructorName(item *objectStorageItem) string {
+	if item.Kind == reflect.Func {
+		if fn := runtime.FuncForPC(reflect.ValueOf(item.Value).Pointer()); fn != nil {
+			return fn.Name() + " " + item.ReflectType.String()
+		}
+	}
+	return item.Address
+}
@@ -13,6 +13,7 @@ import (
 	"go.osspkg.com/casecheck"
 	"go.osspkg.com/errors"
 	"go.osspkg.com/grape/container"
+	"go.osspkg.com/grape/lifecycle"
 	"go.osspkg.com/xc"
 )
 
@@ -105,6 +106,24 @@ func TestUnit_SimpleDI1(t *testing.T) {
 	casecheck.NoError(t, c.Stop())
 }
 
+func TestUnit_ObserveEvents(t *testing.T) {
+	kinds := make([]lifecycle.Kind, 0)
+	c := container.New(xc.New())
+	c.Observe(lifecycle.ObserverFunc(func(e lifecycle.Event) {
+		kinds = append(kinds, e.Kind)
+	}))
+	casecheck.NoError(t, c.Register(func() *SimpleDI1_Service { return &SimpleDI1_Service{ErrDown: "fail"} }))
+	casecheck.NoError(t, c.Start())
+	casecheck.Error(t, c.Stop())
+	casecheck.Equal(t, []lifecycle.Kind{
+		lifecycle.ProviderRegistered,
+		lifecycle.ConstructorStarted,
+		lifecycle.ConstructorFinished,
+		lifecycle.ServiceUp,
+		lifecycle.ServiceDown,
+	}, kinds)
+}
+
 func TestUnit_SimpleDI2(t *testing.T) {
 	c := container.New(xc.New())
 	casecheck.NoError(t, c.Register(&SimpleDI1_A{A: "field A of struct"}))
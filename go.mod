@@ -8,15 +8,14 @@ require (
 	go.osspkg.com/config v0.1.2
 	go.osspkg.com/console v0.3.3
 	go.osspkg.com/errors v0.3.1
-	go.osspkg.com/events v0.3.0
 	go.osspkg.com/logx v0.4.1
 	go.osspkg.com/syncing v0.3.0
 	go.osspkg.com/xc v0.3.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	go.osspkg.com/ioutils v0.4.4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,70 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package activation
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	EnvPID   = "LISTEN_PID"
+	EnvFDs   = "LISTEN_FDS"
+	EnvNames = "LISTEN_FDNAMES"
+
+	// FirstFD first passed descriptor, 0-2 are stdin, stdout and stderr
+	FirstFD = 3
+)
+
+// File descriptor passed by systemd socket activation
+type File struct {
+	Name string
+	File *os.File
+}
+
+// Files returns descriptors passed by systemd (sd_listen_fds), the variables are removed
+// from the environment so child processes do not inherit them
+func Files() ([]File, error) {
+	pid, fds, names := os.Getenv(EnvPID), os.Getenv(EnvFDs), os.Getenv(EnvNames)
+	for _, key := range []string{EnvPID, EnvFDs, EnvNames} {
+		if err := os.Unsetenv(key); err != nil {
+			return nil, err
+		}
+	}
+	if len(pid) == 0 {
+		return nil, nil
+	}
+	if value, err := strconv.Atoi(pid); err != nil || value != os.Getpid() {
+		return nil, nil
+	}
+	return Parse(fds, names, FirstFD)
+}
+
+// Parse returns descriptors from the count and colon separated names, descriptors start from the first
+func Parse(fds, names string, first int) ([]File, error) {
+	if len(fds) == 0 {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid count of descriptors [%s]", fds)
+	}
+	list := strings.Split(names, ":")
+	result := make([]File, 0, count)
+	for i := 0; i < count; i++ {
+		fd := first + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(list) && len(list[i]) > 0 {
+			name = list[i]
+		}
+		syscall.CloseOnExec(fd)
+		result = append(result, File{Name: name, File: os.NewFile(uintptr(fd), name)})
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package activation
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+)
+
+func TestUnit_Files(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer l.Close() //nolint: errcheck
+	lf, err := l.(*net.TCPListener).File()
+	casecheck.NoError(t, err)
+	defer lf.Close() //nolint: errcheck
+
+	// two consecutive descriptors as systemd passes them
+	first, err := syscall.Dup(int(lf.Fd()))
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, syscall.Dup2(first, first+1))
+
+	list, err := Parse("2", "http", first)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 2, len(list))
+	casecheck.Equal(t, "http", list[0].Name)
+	casecheck.Equal(t, "LISTEN_FD_"+strconv.Itoa(first+1), list[1].Name)
+
+	for _, f := range list {
+		fl, err0 := net.FileListener(f.File)
+		casecheck.NoError(t, err0)
+		casecheck.Equal(t, l.Addr().String(), fl.Addr().String())
+		casecheck.NoError(t, fl.Close())
+		casecheck.NoError(t, f.File.Close())
+	}
+
+	_, err = Parse("x", "", FirstFD)
+	casecheck.Error(t, err)
+}
+
+func TestUnit_FilesOtherProcess(t *testing.T) {
+	t.Setenv(EnvPID, strconv.Itoa(os.Getpid()+1))
+	t.Setenv(EnvFDs, "1")
+	list, err := Files()
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 0, len(list))
+	_, ok := os.LookupEnv(EnvFDs)
+	casecheck.False(t, ok)
+}
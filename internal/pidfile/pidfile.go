@@ -0,0 +1,173 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	fileMode = 0644
+	// maxAttempts count of retries when the file is replaced by another process during locking
+	maxAttempts = 10
+)
+
+// ErrLocked pid file is held by another running process
+var ErrLocked = errors.New("pid file is locked by another process")
+
+// File pid file locked with flock for the lifetime of the process
+type File struct {
+	path string
+	file *os.File
+}
+
+// Create lock the pid file and write pid of the current process, a stale file of the stopped
+// process is replaced, if the file is locked by a running process ErrLocked is returned
+func Create(path string) (*File, error) {
+	for i := 0; i < maxAttempts; i++ {
+		prev, err := lockCurrent(path)
+		if err != nil {
+			return nil, err
+		}
+		if prev == nil {
+			// the file was replaced by another process after opening, try again
+			continue
+		}
+		file, err := writeLocked(path)
+		if err0 := prev.Close(); err == nil && err0 != nil {
+			err = err0
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &File{path: path, file: file}, nil
+	}
+	return nil, fmt.Errorf("lock pid file [%s]: file is changing by another process", path)
+}
+
+// lockCurrent lock the current file by the path, returns nil file if the path refers to another file after locking
+func lockCurrent(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, fileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open pid file [%s]: %w", path, err)
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pid, _ := Read(path) // nolint: errcheck
+		_ = file.Close()     // nolint: errcheck
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: [%s] pid %d", ErrLocked, path, pid)
+		}
+		return nil, fmt.Errorf("lock pid file [%s]: %w", path, err)
+	}
+	same, err := isSameFile(path, file)
+	if err != nil || !same {
+		_ = file.Close() // nolint: errcheck
+		return nil, err
+	}
+	return file, nil
+}
+
+// writeLocked write pid to the temporary file, lock it and atomically replace the pid file
+func writeLocked(path string) (*os.File, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("create pid file [%s]: %w", path, err)
+	}
+	fail := func(err error) (*os.File, error) {
+		_ = file.Close()           // nolint: errcheck
+		_ = os.Remove(file.Name()) // nolint: errcheck
+		return nil, fmt.Errorf("create pid file [%s]: %w", path, err)
+	}
+	if err = file.Chmod(fileMode); err != nil {
+		return fail(err)
+	}
+	if _, err = file.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		return fail(err)
+	}
+	if err = file.Sync(); err != nil {
+		return fail(err)
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return fail(err)
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return fail(err)
+	}
+	return file, nil
+}
+
+// Inherit take the pid file locked by the parent process: write pid of the current process to the new
+// locked file, replace the pid file and release the descriptor of the parent
+func Inherit(path string, locked *os.File) (*File, error) {
+	file, err := writeLocked(path)
+	if err0 := locked.Close(); err == nil && err0 != nil {
+		err = err0
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, file: file}, nil
+}
+
+// Read returns pid from the file
+func Read(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// Path returns path of the pid file
+func (v *File) Path() string {
+	return v.path
+}
+
+// File returns locked descriptor of the pid file, it is passed to the new process during upgrade
+func (v *File) File() *os.File {
+	return v.file
+}
+
+// Remove delete the pid file if it is not replaced and release the lock
+func (v *File) Remove() error {
+	if v.file == nil {
+		return nil
+	}
+	var result error
+	if same, err := isSameFile(v.path, v.file); err != nil {
+		result = err
+	} else if same {
+		if err = os.Remove(v.path); err != nil {
+			result = fmt.Errorf("remove pid file [%s]: %w", v.path, err)
+		}
+	}
+	if err := v.file.Close(); err != nil && result == nil {
+		result = err
+	}
+	v.file = nil
+	return result
+}
+
+func isSameFile(path string, file *os.File) (bool, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return os.SameFile(fi, pi), nil
+}
@@ -0,0 +1,82 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package pidfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/internal/pidfile"
+)
+
+func TestUnit_PidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pid")
+
+	f, err := pidfile.Create(path)
+	casecheck.NoError(t, err)
+	pid, err := pidfile.Read(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, os.Getpid(), pid)
+	info, err := os.Stat(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	_, err = pidfile.Create(path)
+	casecheck.True(t, errors.Is(err, pidfile.ErrLocked))
+
+	casecheck.NoError(t, f.Remove())
+	_, err = os.Stat(path)
+	casecheck.True(t, os.IsNotExist(err))
+
+	list, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 0, len(list))
+}
+
+func TestUnit_PidFileStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pid")
+	casecheck.NoError(t, os.WriteFile(path, []byte("999999\n"), 0600))
+
+	f, err := pidfile.Create(path)
+	casecheck.NoError(t, err)
+	pid, err := pidfile.Read(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, os.Getpid(), pid)
+
+	// the file replaced by somebody else is not removed
+	casecheck.NoError(t, os.Remove(path))
+	casecheck.NoError(t, os.WriteFile(path, []byte("1\n"), 0600))
+	casecheck.NoError(t, f.Remove())
+	pid, err = pidfile.Read(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 1, pid)
+}
+
+func TestUnit_PidFileInherit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pid")
+	parent, err := pidfile.Create(path)
+	casecheck.NoError(t, err)
+
+	fd, err := syscall.Dup(int(parent.File().Fd()))
+	casecheck.NoError(t, err)
+	child, err := pidfile.Inherit(path, os.NewFile(uintptr(fd), "pidfile"))
+	casecheck.NoError(t, err)
+
+	// the parent does not remove the file of the child
+	casecheck.NoError(t, parent.Remove())
+	_, err = pidfile.Read(path)
+	casecheck.NoError(t, err)
+
+	_, err = pidfile.Create(path)
+	casecheck.True(t, errors.Is(err, pidfile.ErrLocked))
+	casecheck.NoError(t, child.Remove())
+	_, err = os.Stat(path)
+	casecheck.True(t, os.IsNotExist(err))
+}
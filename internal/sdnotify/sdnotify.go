@@ -0,0 +1,95 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EnvSocket      = "NOTIFY_SOCKET"
+	EnvWatchdogSec = "WATCHDOG_USEC"
+	EnvWatchdogPID = "WATCHDOG_PID"
+
+	Ready     = "READY=1"
+	Stopping  = "STOPPING=1"
+	Reloading = "RELOADING=1"
+	Watchdog  = "WATCHDOG=1"
+	status    = "STATUS="
+	mainPID   = "MAINPID="
+	monotonic = "MONOTONIC_USEC="
+)
+
+// Notifier sends state of the service to systemd (sd_notify), it does nothing without the socket
+type Notifier struct {
+	socket string
+}
+
+// New create notifier with the socket from NOTIFY_SOCKET
+func New() *Notifier {
+	return NewWithSocket(os.Getenv(EnvSocket))
+}
+
+// NewWithSocket create notifier with the socket path, path with @ prefix is the abstract socket
+func NewWithSocket(socket string) *Notifier {
+	return &Notifier{socket: socket}
+}
+
+// Enabled returns true if the service is started by systemd with notify type
+func (v *Notifier) Enabled() bool {
+	return len(v.socket) > 0
+}
+
+// Notify send states in one message: READY=1, STATUS=...
+func (v *Notifier) Notify(states ...string) error {
+	if !v.Enabled() || len(states) == 0 {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: v.socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+	if err0 := conn.Close(); err == nil {
+		err = err0
+	}
+	return err
+}
+
+// Status returns state with status text of the service
+func Status(text string) string {
+	return status + strings.ReplaceAll(text, "\n", " ")
+}
+
+// MainPID returns state with pid of the new main process of the service
+func MainPID(pid int) string {
+	return mainPID + strconv.Itoa(pid)
+}
+
+// Monotonic returns state with the current time of CLOCK_MONOTONIC, it is required with RELOADING=1
+func Monotonic() string {
+	return monotonic + strconv.FormatInt(monotonicTime()/int64(time.Microsecond), 10)
+}
+
+// WatchdogInterval returns interval of watchdog pings, it is half of WATCHDOG_USEC,
+// false if the watchdog is disabled or it is set for another process
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv(EnvWatchdogSec), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if value := os.Getenv(EnvWatchdogPID); len(value) > 0 {
+		pid, err0 := strconv.Atoi(value)
+		if err0 != nil || pid != os.Getpid() {
+			return 0, false
+		}
+	}
+	return time.Duration(usec) * time.Microsecond / 2, true
+}
@@ -0,0 +1,64 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package sdnotify_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/internal/sdnotify"
+)
+
+func TestUnit_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+
+	n := sdnotify.NewWithSocket(path)
+	casecheck.True(t, n.Enabled())
+	casecheck.NoError(t, n.Notify(sdnotify.Ready, sdnotify.Status("Running\nok")))
+
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	size, err := conn.Read(buf)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "READY=1\nSTATUS=Running ok", string(buf[:size]))
+
+	off := sdnotify.NewWithSocket("")
+	casecheck.False(t, off.Enabled())
+	casecheck.NoError(t, off.Notify(sdnotify.Ready))
+}
+
+func TestUnit_WatchdogInterval(t *testing.T) {
+	t.Setenv(sdnotify.EnvWatchdogSec, "")
+	_, ok := sdnotify.WatchdogInterval()
+	casecheck.False(t, ok)
+
+	t.Setenv(sdnotify.EnvWatchdogSec, "3000000")
+	interval, ok := sdnotify.WatchdogInterval()
+	casecheck.True(t, ok)
+	casecheck.Equal(t, 1500*time.Millisecond, interval)
+
+	t.Setenv(sdnotify.EnvWatchdogPID, strconv.Itoa(os.Getpid()+1))
+	_, ok = sdnotify.WatchdogInterval()
+	casecheck.False(t, ok)
+}
+
+func TestUnit_Monotonic(t *testing.T) {
+	state := sdnotify.Monotonic()
+	casecheck.True(t, strings.HasPrefix(state, "MONOTONIC_USEC="))
+
+	usec, err := strconv.ParseInt(strings.TrimPrefix(state, "MONOTONIC_USEC="), 10, 64)
+	casecheck.NoError(t, err)
+	casecheck.True(t, usec > 0)
+}
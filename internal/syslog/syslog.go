@@ -0,0 +1,324 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	NetworkUDP      = "udp"
+	NetworkTCP      = "tcp"
+	NetworkUnix     = "unix"
+	NetworkUnixgram = "unixgram"
+
+	DefaultBuffer  = 1000
+	DefaultTimeout = 5 * time.Second
+
+	minRetryDelay = 100 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+var localPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type (
+	// Config connection to the syslog server
+	Config struct {
+		// Network udp, tcp, unix, unixgram, empty - local syslog daemon
+		Network string
+		Address string
+		// Buffer count of messages kept while the connection is down, the oldest are dropped
+		Buffer int
+		// Timeout of dial and write
+		Timeout time.Duration
+	}
+
+	// Writer sends written messages to the syslog server in background, reconnects after errors
+	// and keeps messages in the buffer until the connection is restored
+	Writer struct {
+		conf Config
+		// the connection is used only by the sender
+		conn    net.Conn
+		network string
+		delay   time.Duration
+		retryAt time.Time
+		// removed count of messages removed from the queue, the sender checks
+		// that the sent message is not dropped while writing
+		queue   [][]byte
+		removed uint64
+		dropped uint64
+		closed  bool
+		err     error
+		mux     sync.Mutex
+		wake    chan struct{}
+		done    chan struct{}
+	}
+)
+
+// ParseAddress parse address in form: udp://host:514, tcp://host:601, unix:///dev/log,
+// empty address or the path of a file (/dev/stdout from the default config) - local syslog daemon
+func ParseAddress(address string) (network, addr string, err error) {
+	if !strings.Contains(address, "://") {
+		return "", "", nil
+	}
+	uri, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog address [%s]: %w", address, err)
+	}
+	switch uri.Scheme {
+	case NetworkUDP, NetworkTCP:
+		addr = uri.Host
+	case NetworkUnix, NetworkUnixgram:
+		addr = uri.Host + uri.Path
+	default:
+		return "", "", fmt.Errorf("unsupported syslog network [%s]", uri.Scheme)
+	}
+	if len(addr) == 0 {
+		return "", "", fmt.Errorf("invalid syslog address [%s]: host or path is empty", address)
+	}
+	return uri.Scheme, addr, nil
+}
+
+// New create writer, the connection is opened by the sender, the writer reconnects after errors
+func New(conf Config) (*Writer, error) {
+	switch conf.Network {
+	case "":
+	case NetworkUDP, NetworkTCP, NetworkUnix, NetworkUnixgram:
+		if len(conf.Address) == 0 {
+			return nil, fmt.Errorf("syslog address is empty")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported syslog network [%s]", conf.Network)
+	}
+	if conf.Buffer <= 0 {
+		conf.Buffer = DefaultBuffer
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DefaultTimeout
+	}
+
+	v := &Writer{
+		conf: conf,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go v.run()
+	return v, nil
+}
+
+// Write add one message to the queue of the sender, the oldest message is dropped if the queue is full
+func (v *Writer) Write(p []byte) (int, error) {
+	v.mux.Lock()
+	if v.closed {
+		v.mux.Unlock()
+		return 0, os.ErrClosed
+	}
+	msg := make([]byte, len(bytes.TrimRight(p, "\r\n")))
+	copy(msg, p)
+	if len(v.queue) >= v.conf.Buffer {
+		v.pop()
+		v.dropped++
+	}
+	v.queue = append(v.queue, msg)
+	v.mux.Unlock()
+
+	v.notify()
+	return len(p), nil
+}
+
+// Dropped returns count of messages removed from the full buffer
+func (v *Writer) Dropped() uint64 {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	return v.dropped
+}
+
+// Close wait for the sender to try to send buffered messages and close the connection
+func (v *Writer) Close() error {
+	v.mux.Lock()
+	if v.closed {
+		v.mux.Unlock()
+		return nil
+	}
+	v.closed = true
+	v.mux.Unlock()
+
+	v.notify()
+	<-v.done
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	v.queue = nil
+	return v.err
+}
+
+func (v *Writer) notify() {
+	select {
+	case v.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run the sender: messages are sent after writing and after the retry delay,
+// dial and write of the connection are done without the lock
+func (v *Writer) run() {
+	defer close(v.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		v.mux.Lock()
+		closed := v.closed
+		v.mux.Unlock()
+
+		if closed {
+			v.retryAt = time.Time{}
+			err := v.flush()
+			if v.conn != nil {
+				if err0 := v.conn.Close(); err == nil {
+					err = err0
+				}
+				v.conn = nil
+			}
+			v.mux.Lock()
+			v.err = err
+			v.mux.Unlock()
+			return
+		}
+
+		if err := v.flush(); err != nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(v.retryAt))
+			select {
+			case <-v.wake:
+			case <-timer.C:
+			}
+			continue
+		}
+		<-v.wake
+	}
+}
+
+func (v *Writer) flush() error {
+	reconnected := false
+	for {
+		v.mux.Lock()
+		if len(v.queue) == 0 {
+			v.mux.Unlock()
+			return nil
+		}
+		msg, removed := v.queue[0], v.removed
+		v.mux.Unlock()
+
+		if v.conn == nil {
+			if err := v.connect(); err != nil {
+				return err
+			}
+		}
+		if err := v.send(msg); err != nil {
+			v.disconnect()
+			if reconnected {
+				v.backoff()
+				return err
+			}
+			reconnected = true
+			continue
+		}
+
+		v.mux.Lock()
+		// the message is not dropped from the full queue while sending
+		if v.removed == removed {
+			v.pop()
+		}
+		v.mux.Unlock()
+	}
+}
+
+func (v *Writer) pop() {
+	v.queue[0] = nil
+	v.queue = v.queue[1:]
+	v.removed++
+}
+
+func (v *Writer) connect() error {
+	if time.Now().Before(v.retryAt) {
+		return fmt.Errorf("syslog is not available, retry at %s", v.retryAt.Format(time.RFC3339))
+	}
+	conn, network, err := v.dial()
+	if err != nil {
+		v.backoff()
+		return err
+	}
+	v.conn, v.network = conn, network
+	v.delay = 0
+	return nil
+}
+
+func (v *Writer) dial() (net.Conn, string, error) {
+	if len(v.conf.Network) > 0 {
+		conn, err := net.DialTimeout(v.conf.Network, v.conf.Address, v.conf.Timeout)
+		return conn, v.conf.Network, err
+	}
+	for _, network := range []string{NetworkUnixgram, NetworkUnix} {
+		for _, path := range localPaths {
+			if conn, err := net.DialTimeout(network, path, v.conf.Timeout); err == nil {
+				return conn, network, nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("local syslog daemon is not available")
+}
+
+func (v *Writer) disconnect() {
+	if v.conn != nil {
+		_ = v.conn.Close() // nolint: errcheck
+		v.conn = nil
+	}
+}
+
+func (v *Writer) backoff() {
+	v.delay *= 2
+	if v.delay < minRetryDelay {
+		v.delay = minRetryDelay
+	}
+	if v.delay > maxRetryDelay {
+		v.delay = maxRetryDelay
+	}
+	v.retryAt = time.Now().Add(v.delay)
+}
+
+// send write message with framing of the transport: octet counting for tcp (RFC 6587),
+// new line for unix stream, one datagram for udp and unixgram
+func (v *Writer) send(msg []byte) error {
+	if err := v.conn.SetWriteDeadline(time.Now().Add(v.conf.Timeout)); err != nil {
+		return err
+	}
+	var data []byte
+	switch v.network {
+	case NetworkTCP:
+		data = append(append([]byte(strconv.Itoa(len(msg))), ' '), msg...)
+	case NetworkUnix:
+		data = append(append(make([]byte, 0, len(msg)+1), msg...), '\n')
+	default:
+		data = msg
+	}
+	_, err := v.conn.Write(data)
+	return err
+}
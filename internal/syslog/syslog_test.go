@@ -0,0 +1,165 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package syslog_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/internal/syslog"
+)
+
+func TestUnit_ParseAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		network string
+		addr    string
+		err     bool
+	}{
+		{address: "", network: "", addr: ""},
+		{address: "/dev/stdout", network: "", addr: ""},
+		{address: "/var/log/app.log", network: "", addr: ""},
+		{address: "udp://127.0.0.1:514", network: "udp", addr: "127.0.0.1:514"},
+		{address: "tcp://logs:601", network: "tcp", addr: "logs:601"},
+		{address: "unix:///dev/log", network: "unix", addr: "/dev/log"},
+		{address: "unixgram:///dev/log", network: "unixgram", addr: "/dev/log"},
+		{address: "http://logs", err: true},
+		{address: "udp://", err: true},
+	}
+	for _, c := range cases {
+		network, addr, err := syslog.ParseAddress(c.address)
+		if c.err {
+			casecheck.Error(t, err)
+			continue
+		}
+		casecheck.NoError(t, err)
+		casecheck.Equal(t, c.network, network)
+		casecheck.Equal(t, c.addr, addr)
+	}
+}
+
+func TestUnit_WriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+
+	w, err := syslog.New(syslog.Config{Network: syslog.NetworkUDP, Address: conn.LocalAddr().String()})
+	casecheck.NoError(t, err)
+	_, err = w.Write([]byte("<14>1 - - - - - - hello\n"))
+	casecheck.NoError(t, err)
+
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "<14>1 - - - - - - hello", string(buf[:n]))
+	casecheck.NoError(t, w.Close())
+}
+
+func TestUnit_WriterUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+
+	w, err := syslog.New(syslog.Config{Network: syslog.NetworkUnixgram, Address: path})
+	casecheck.NoError(t, err)
+	_, err = w.Write([]byte("message"))
+	casecheck.NoError(t, err)
+
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "message", string(buf[:n]))
+	casecheck.NoError(t, w.Close())
+}
+
+func TestUnit_WriterTCPBuffer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	address := l.Addr().String()
+	casecheck.NoError(t, l.Close())
+
+	w, err := syslog.New(syslog.Config{Network: syslog.NetworkTCP, Address: address, Buffer: 3})
+	casecheck.NoError(t, err)
+	for i := 1; i <= 4; i++ {
+		_, err = w.Write([]byte("message " + strconv.Itoa(i)))
+		casecheck.NoError(t, err)
+	}
+	casecheck.Equal(t, uint64(1), w.Dropped())
+
+	l, err = net.Listen("tcp", address)
+	casecheck.NoError(t, err)
+	defer l.Close() //nolint: errcheck
+
+	// the sender reconnects after the retry delay without new messages
+	conn, err := l.Accept()
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	r := bufio.NewReader(conn)
+	read := func() string {
+		size, err0 := r.ReadString(' ')
+		casecheck.NoError(t, err0)
+		n, err0 := strconv.Atoi(strings.TrimSpace(size))
+		casecheck.NoError(t, err0)
+		msg := make([]byte, n)
+		_, err0 = io.ReadFull(r, msg)
+		casecheck.NoError(t, err0)
+		return string(msg)
+	}
+	for i := 2; i <= 4; i++ {
+		casecheck.Equal(t, "message "+strconv.Itoa(i), read())
+	}
+
+	_, err = w.Write([]byte("message 5"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "message 5", read())
+	casecheck.Equal(t, uint64(1), w.Dropped())
+	casecheck.NoError(t, w.Close())
+	_, err = w.Write([]byte("message 6"))
+	casecheck.Error(t, err)
+}
+
+func TestUnit_WriterNotBlocked(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer l.Close() //nolint: errcheck
+	release := make(chan struct{})
+	go func() {
+		// the server does not read until the release
+		conn, err0 := l.Accept()
+		if err0 != nil {
+			return
+		}
+		defer conn.Close() //nolint: errcheck
+		<-release
+		_, _ = io.Copy(io.Discard, conn) //nolint: errcheck
+	}()
+
+	w, err := syslog.New(syslog.Config{Network: syslog.NetworkTCP, Address: l.Addr().String(), Timeout: 5 * time.Second})
+	casecheck.NoError(t, err)
+
+	msg := []byte(strings.Repeat("a", 1<<20))
+	started := time.Now()
+	for i := 0; i < 32; i++ {
+		_, err = w.Write(msg)
+		casecheck.NoError(t, err)
+	}
+	casecheck.True(t, time.Since(started) < 2*time.Second)
+	close(release)
+	casecheck.NoError(t, w.Close())
+	casecheck.Equal(t, uint64(0), w.Dropped())
+}
@@ -0,0 +1,260 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.osspkg.com/grape/internal/activation"
+	"go.osspkg.com/grape/internal/sdnotify"
+)
+
+const (
+	EnvFDs     = "GRAPE_UPGRADE_FDS"
+	EnvNames   = "GRAPE_UPGRADE_FDNAMES"
+	EnvReadyFD = "GRAPE_UPGRADE_READY_FD"
+	EnvPidFD   = "GRAPE_UPGRADE_PID_FD"
+	// EnvRestarts count of upgrades of the application since the first start
+	EnvRestarts = "GRAPE_UPGRADE_RESTARTS"
+
+	readyMessage   = "READY=1\n"
+	confirmMessage = "MAINPID=1\n"
+)
+
+type (
+	// Child new copy of the application started with descriptors of the current process
+	Child struct {
+		cmd   *exec.Cmd
+		ready *os.File
+		done  chan error
+	}
+
+	// Inheritance descriptors received by the new copy of the application from the parent
+	Inheritance struct {
+		Listeners []activation.File
+		// PidFile locked pid file of the parent, nil if the pid file is not used
+		PidFile *os.File
+		// Restarts count of upgrades before the current process
+		Restarts int
+		ready    *os.File
+	}
+)
+
+// Start run the binary of the application with the same arguments, listeners and the locked pid file
+// are passed as inherited descriptors, restarts is the count of upgrades before the current process
+func Start(listeners []activation.File, pidFile *os.File, restarts int) (*Child, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := readyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*os.File, 0, len(listeners)+2)
+	names := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		files = append(files, l.File)
+		names = append(names, l.Name)
+	}
+	env := cleanEnv(os.Environ())
+	env = append(env,
+		EnvFDs+"="+strconv.Itoa(len(listeners)),
+		EnvNames+"="+strings.Join(names, ":"),
+		EnvReadyFD+"="+strconv.Itoa(activation.FirstFD+len(files)),
+		EnvRestarts+"="+strconv.Itoa(restarts+1),
+	)
+	files = append(files, w)
+	if pidFile != nil {
+		env = append(env, EnvPidFD+"="+strconv.Itoa(activation.FirstFD+len(files)))
+		files = append(files, pidFile)
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...) // nolint: gosec
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+	cmd.ExtraFiles = files
+	err = cmd.Start()
+	// the child has own copy of the write end
+	_ = w.Close() // nolint: errcheck
+	if err != nil {
+		_ = r.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	v := &Child{cmd: cmd, ready: r, done: make(chan error, 1)}
+	go func() { v.done <- cmd.Wait() }()
+	return v, nil
+}
+
+// Pid returns pid of the child
+func (v *Child) Pid() int {
+	return v.cmd.Process.Pid
+}
+
+// WaitReady wait for the ready message of the child, the child is killed on error or timeout,
+// after success the child waits for Confirm
+func (v *Child) WaitReady(timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		b := make([]byte, len(readyMessage))
+		if _, err := io.ReadFull(v.ready, b); err != nil {
+			result <- fmt.Errorf("read ready message: %w", err)
+			return
+		}
+		if string(b) != readyMessage {
+			result <- fmt.Errorf("unexpected ready message [%s]", string(b))
+			return
+		}
+		result <- nil
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-result:
+	case err = <-v.done:
+		err = fmt.Errorf("child exited before ready: %v", err)
+	case <-timer.C:
+		err = fmt.Errorf("child is not ready after %s", timeout)
+	}
+	if err != nil {
+		_ = v.ready.Close()      // nolint: errcheck
+		_ = v.cmd.Process.Kill() // nolint: errcheck
+		return err
+	}
+	return nil
+}
+
+// Confirm allow the child to report readiness to systemd, it is called after MAINPID is changed to the child,
+// otherwise the notifications of the child are dropped with NotifyAccess=main
+func (v *Child) Confirm() error {
+	_, err := v.ready.WriteString(confirmMessage)
+	if err0 := v.ready.Close(); err == nil {
+		err = err0
+	}
+	return err
+}
+
+// Inherited returns descriptors passed by the parent during upgrade, nil if the process is not
+// started by upgrade, the variables are removed from the environment
+func Inherited() (*Inheritance, error) {
+	fds, names := os.Getenv(EnvFDs), os.Getenv(EnvNames)
+	readyFD, pidFD, restarts := os.Getenv(EnvReadyFD), os.Getenv(EnvPidFD), os.Getenv(EnvRestarts)
+	for _, key := range []string{EnvFDs, EnvNames, EnvReadyFD, EnvPidFD, EnvRestarts} {
+		if err := os.Unsetenv(key); err != nil {
+			return nil, err
+		}
+	}
+	if len(readyFD) == 0 {
+		return nil, nil
+	}
+
+	v := &Inheritance{}
+	v.Restarts, _ = strconv.Atoi(restarts) // nolint: errcheck
+	var err error
+	if v.ready, err = inheritedFile(readyFD, "ready"); err != nil {
+		return nil, err
+	}
+	if len(pidFD) > 0 {
+		if v.PidFile, err = inheritedFile(pidFD, "pidfile"); err != nil {
+			v.Close()
+			return nil, err
+		}
+	}
+	if v.Listeners, err = activation.Parse(fds, names, activation.FirstFD); err != nil {
+		v.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+// Ready send ready message to the parent and wait for the confirmation,
+// after it the parent stops and the current process is the main process of the service
+func (v *Inheritance) Ready() error {
+	if v.ready == nil {
+		return nil
+	}
+	_, err := v.ready.WriteString(readyMessage)
+	if err == nil {
+		b := make([]byte, len(confirmMessage))
+		if _, err = io.ReadFull(v.ready, b); err != nil {
+			err = fmt.Errorf("read confirmation: %w", err)
+		} else if string(b) != confirmMessage {
+			err = fmt.Errorf("unexpected confirmation [%s]", string(b))
+		}
+	}
+	if err0 := v.ready.Close(); err == nil {
+		err = err0
+	}
+	v.ready = nil
+	return err
+}
+
+// Close release descriptors of the parent, listeners are closed by their owner
+func (v *Inheritance) Close() {
+	for _, f := range []*os.File{v.ready, v.PidFile} {
+		if f != nil {
+			_ = f.Close() // nolint: errcheck
+		}
+	}
+	v.ready, v.PidFile = nil, nil
+}
+
+// readyPair create connected sockets for the ready message and the confirmation,
+// the end of the parent is non-blocking to be closed on timeout
+func readyPair() (*os.File, *os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	syscall.CloseOnExec(fds[0])
+	syscall.CloseOnExec(fds[1])
+	if err = syscall.SetNonblock(fds[0], true); err != nil {
+		_ = syscall.Close(fds[0]) // nolint: errcheck
+		_ = syscall.Close(fds[1]) // nolint: errcheck
+		return nil, nil, err
+	}
+	return os.NewFile(uintptr(fds[0]), "ready"), os.NewFile(uintptr(fds[1]), "ready"), nil
+}
+
+func inheritedFile(value, name string) (*os.File, error) {
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < activation.FirstFD {
+		return nil, fmt.Errorf("invalid inherited descriptor [%s=%s]", name, value)
+	}
+	syscall.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// cleanEnv remove descriptors of the previous upgrade and socket activation,
+// the watchdog pid of the parent is removed so the child sends watchdog pings itself
+func cleanEnv(env []string) []string {
+	result := make([]string, 0, len(env)+4)
+	for _, item := range env {
+		key := item
+		if i := strings.Index(item, "="); i >= 0 {
+			key = item[:i]
+		}
+		switch key {
+		case EnvFDs, EnvNames, EnvReadyFD, EnvPidFD, EnvRestarts, activation.EnvPID, activation.EnvFDs, activation.EnvNames,
+			sdnotify.EnvWatchdogPID:
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package upgrade_test
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/internal/activation"
+	"go.osspkg.com/grape/internal/sdnotify"
+	"go.osspkg.com/grape/internal/upgrade"
+)
+
+const envTestChild = "GRAPE_TEST_UPGRADE_CHILD"
+
+// TestMain the test binary is started again as the child process
+func TestMain(m *testing.M) {
+	mode, ok := os.LookupEnv(envTestChild)
+	if !ok {
+		os.Exit(m.Run())
+	}
+	inherited, err := upgrade.Inherited()
+	if err != nil || inherited == nil || mode == "fail" {
+		os.Exit(1)
+	}
+	if len(inherited.Listeners) != 1 || inherited.Listeners[0].Name != "http" || inherited.Restarts != 3 {
+		os.Exit(2)
+	}
+	if _, err = net.FileListener(inherited.Listeners[0].File); err != nil {
+		os.Exit(3)
+	}
+	if _, ok = sdnotify.WatchdogInterval(); mode == "watchdog" && !ok {
+		os.Exit(5)
+	}
+	if err = inherited.Ready(); err != nil {
+		os.Exit(4)
+	}
+	os.Exit(0)
+}
+
+func startChild(t *testing.T, mode string) (*upgrade.Child, error) {
+	t.Setenv(envTestChild, mode)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer l.Close() //nolint: errcheck
+	file, err := l.(*net.TCPListener).File()
+	casecheck.NoError(t, err)
+	defer file.Close() //nolint: errcheck
+
+	return upgrade.Start([]activation.File{{Name: "http", File: file}}, nil, 2)
+}
+
+func TestUnit_Upgrade(t *testing.T) {
+	child, err := startChild(t, "ready")
+	casecheck.NoError(t, err)
+	casecheck.True(t, child.Pid() > 0)
+	casecheck.NoError(t, child.WaitReady(10*time.Second))
+	casecheck.NoError(t, child.Confirm())
+}
+
+func TestUnit_UpgradeWatchdog(t *testing.T) {
+	t.Setenv(sdnotify.EnvWatchdogSec, "3000000")
+	t.Setenv(sdnotify.EnvWatchdogPID, strconv.Itoa(os.Getpid()))
+
+	child, err := startChild(t, "watchdog")
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, child.WaitReady(10*time.Second))
+	casecheck.NoError(t, child.Confirm())
+}
+
+func TestUnit_UpgradeFail(t *testing.T) {
+	child, err := startChild(t, "fail")
+	casecheck.NoError(t, err)
+	casecheck.Error(t, child.WaitReady(10*time.Second))
+}
+
+func TestUnit_Inherited(t *testing.T) {
+	t.Setenv(upgrade.EnvReadyFD, "")
+	inherited, err := upgrade.Inherited()
+	casecheck.NoError(t, err)
+	casecheck.Nil(t, inherited)
+}
@@ -0,0 +1,189 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Kind string
+
+const (
+	ProviderRegistered  Kind = "provider-registered"
+	ConstructorStarted  Kind = "constructor-started"
+	ConstructorFinished Kind = "constructor-finished"
+	ServiceUp           Kind = "service-up"
+	ServiceDown         Kind = "service-down"
+	ConfigReloaded      Kind = "config-reloaded"
+	ShutdownStarted     Kind = "shutdown-started"
+)
+
+type (
+	// Event transition of the application lifecycle,
+	// Duration and Err are set for finished constructors, services and config reload
+	Event struct {
+		Kind     Kind
+		Name     string
+		Time     time.Time
+		Duration time.Duration
+		Err      error
+	}
+
+	// Observer receives events synchronously in the goroutine of the transition, it must not block
+	Observer interface {
+		OnEvent(e Event)
+	}
+
+	// ObserverFunc function as Observer
+	ObserverFunc func(e Event)
+
+	// Subscriber channel subscription for modules, events are dropped if the channel buffer is full,
+	// channels are closed by cancel func or after stopping of the application
+	Subscriber interface {
+		Subscribe(buffer int) (events <-chan Event, cancel func())
+	}
+)
+
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// Emit send event to the observer, nil observer is skipped
+func Emit(o Observer, kind Kind, name string, started time.Time, err error) {
+	if o == nil {
+		return
+	}
+	e := Event{Kind: kind, Name: name, Time: time.Now(), Err: err}
+	if !started.IsZero() {
+		e.Duration = e.Time.Sub(started)
+	}
+	o.OnEvent(e)
+}
+
+/**********************************************************************************************************************/
+
+// Hub sends events to all observers and subscriptions,
+// observers are called without the lock and may subscribe or cancel subscriptions
+type Hub struct {
+	observers []Observer
+	subs      map[*subscription]struct{}
+	closed    bool
+	dropped   uint64
+	mux       sync.RWMutex
+}
+
+type subscription struct {
+	ch     chan Event
+	closed bool
+	mux    sync.Mutex
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[*subscription]struct{}),
+	}
+}
+
+// Observe add observers
+func (h *Hub) Observe(observers ...Observer) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.observers = append(h.observers, observers...)
+}
+
+func (h *Hub) OnEvent(e Event) {
+	h.mux.RLock()
+	observers := make([]Observer, len(h.observers))
+	copy(observers, h.observers)
+	subs := make([]*subscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+	}
+	h.mux.RUnlock()
+
+	for _, o := range observers {
+		o.OnEvent(e)
+	}
+	for _, sub := range subs {
+		if !sub.send(e) {
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+}
+
+func (h *Hub) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	sub := &subscription{ch: make(chan Event, buffer)}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.closed {
+		sub.close()
+		return sub.ch, func() {}
+	}
+	h.subs[sub] = struct{}{}
+	return sub.ch, func() {
+		h.mux.Lock()
+		delete(h.subs, sub)
+		h.mux.Unlock()
+
+		sub.close()
+	}
+}
+
+// Dropped returns count of events not delivered to subscriptions with full buffer
+func (h *Hub) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Close close channels of all subscriptions
+func (h *Hub) Close() {
+	h.mux.Lock()
+	subs := make([]*subscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+		delete(h.subs, sub)
+	}
+	h.closed = true
+	h.mux.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// send the event without blocking, false if the buffer is full,
+// events for the canceled subscription are skipped
+func (v *subscription) send(e Event) bool {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.closed {
+		return true
+	}
+	select {
+	case v.ch <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v *subscription) close() {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if !v.closed {
+		v.closed = true
+		close(v.ch)
+	}
+}
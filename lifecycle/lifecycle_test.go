@@ -0,0 +1,84 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package lifecycle_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/lifecycle"
+)
+
+func TestUnit_Hub(t *testing.T) {
+	hub := lifecycle.NewHub()
+	observed := make([]lifecycle.Event, 0)
+	hub.Observe(lifecycle.ObserverFunc(func(e lifecycle.Event) { observed = append(observed, e) }))
+
+	events, cancel := hub.Subscribe(1)
+	closed, cancel2 := hub.Subscribe(1)
+	cancel2()
+	_, ok := <-closed
+	casecheck.False(t, ok)
+
+	lifecycle.Emit(hub, lifecycle.ConfigReloaded, "app", time.Now().Add(-time.Second), errors.New("fail"))
+	lifecycle.Emit(hub, lifecycle.ShutdownStarted, "app", time.Time{}, nil)
+	lifecycle.Emit(nil, lifecycle.ShutdownStarted, "app", time.Time{}, nil)
+
+	casecheck.Equal(t, 2, len(observed))
+	casecheck.Equal(t, lifecycle.ConfigReloaded, observed[0].Kind)
+	casecheck.True(t, observed[0].Duration >= time.Second)
+	casecheck.Error(t, observed[0].Err)
+	casecheck.Equal(t, time.Duration(0), observed[1].Duration)
+
+	e := <-events
+	casecheck.Equal(t, lifecycle.ConfigReloaded, e.Kind)
+	casecheck.Equal(t, uint64(1), hub.Dropped())
+
+	hub.Close()
+	_, ok = <-events
+	casecheck.False(t, ok)
+	cancel()
+
+	events, _ = hub.Subscribe(1)
+	_, ok = <-events
+	casecheck.False(t, ok)
+}
+
+func TestUnit_HubReentrant(t *testing.T) {
+	hub := lifecycle.NewHub()
+	var events <-chan lifecycle.Event
+	var cancel func()
+	hub.Observe(lifecycle.ObserverFunc(func(e lifecycle.Event) {
+		switch e.Kind {
+		case lifecycle.ServiceUp:
+			events, cancel = hub.Subscribe(1)
+		case lifecycle.ServiceDown:
+			cancel()
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lifecycle.Emit(hub, lifecycle.ServiceUp, "srv", time.Time{}, nil)
+		lifecycle.Emit(hub, lifecycle.ConfigReloaded, "app", time.Time{}, nil)
+		lifecycle.Emit(hub, lifecycle.ServiceDown, "srv", time.Time{}, nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("observer is blocked by the hub")
+	}
+
+	e, ok := <-events
+	casecheck.True(t, ok)
+	casecheck.Equal(t, lifecycle.ConfigReloaded, e.Kind)
+	_, ok = <-events
+	casecheck.False(t, ok)
+	hub.Close()
+}
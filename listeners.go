@@ -0,0 +1,225 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+
+	"go.osspkg.com/errors"
+	"go.osspkg.com/grape/config"
+	"go.osspkg.com/grape/internal/activation"
+)
+
+const (
+	listenerSourceConfig  = "config"
+	listenerSourceSystemd = "systemd"
+	listenerSourceUpgrade = "upgrade"
+)
+
+type (
+	// Listeners registry of network listeners by name, listeners are opened from config or received
+	// from systemd socket activation and are closed by the application at shutdown
+	Listeners interface {
+		// Listener returns listener by name
+		Listener(name string) (net.Listener, error)
+		// Names returns names of all listeners
+		Names() []string
+	}
+
+	_listeners struct {
+		items map[string]*_listener
+		mux   sync.RWMutex
+	}
+	_listener struct {
+		listener net.Listener
+		source   string
+	}
+)
+
+func newListeners() *_listeners {
+	return &_listeners{
+		items: make(map[string]*_listener),
+	}
+}
+
+func (v *_listeners) Listener(name string) (net.Listener, error) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	if item, ok := v.items[name]; ok {
+		return item.listener, nil
+	}
+	return nil, fmt.Errorf("listener [%s] is not found", name)
+}
+
+func (v *_listeners) Names() []string {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	result := make([]string, 0, len(v.items))
+	for name := range v.items {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Add register listener with the name, the owner of the listener becomes the registry
+func (v *_listeners) Add(name, source string, l net.Listener) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if _, ok := v.items[name]; ok {
+		return fmt.Errorf("listener [%s] already exists", name)
+	}
+	v.items[name] = &_listener{listener: l, source: source}
+	return nil
+}
+
+// Activate register listeners passed by systemd socket activation
+func (v *_listeners) Activate() error {
+	files, err := activation.Files()
+	if err != nil {
+		return err
+	}
+	return v.addFiles(files, listenerSourceSystemd)
+}
+
+func (v *_listeners) addFiles(files []activation.File, source string) error {
+	var result error
+	for _, f := range files {
+		l, err := net.FileListener(f.File)
+		// the listener has own copy of the descriptor
+		err = errors.Wrap(err, f.File.Close())
+		if err != nil {
+			result = errors.Wrap(result, fmt.Errorf("listener [%s]: %w", f.Name, err))
+			continue
+		}
+		result = errors.Wrap(result, v.Add(f.Name, source, l))
+	}
+	return result
+}
+
+// Open open listeners from config which are not received from systemd
+func (v *_listeners) Open(conf map[string]config.ListenerConfig) error {
+	names := make([]string, 0, len(conf))
+	for name := range conf {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v.mux.RLock()
+		_, ok := v.items[name]
+		v.mux.RUnlock()
+		if ok {
+			continue
+		}
+		l, err := listen(conf[name])
+		if err != nil {
+			return fmt.Errorf("open listener [%s]: %w", name, err)
+		}
+		if err = v.Add(name, listenerSourceConfig, l); err != nil {
+			return errors.Wrap(err, l.Close())
+		}
+	}
+	return nil
+}
+
+// Files returns copies of descriptors of all listeners
+func (v *_listeners) Files() ([]activation.File, error) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	result := make([]activation.File, 0, len(v.items))
+	for name, item := range v.items {
+		l, ok := item.listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return closeFiles(result), fmt.Errorf("listener [%s] does not support descriptor copy", name)
+		}
+		f, err := l.File()
+		if err != nil {
+			return closeFiles(result), fmt.Errorf("listener [%s]: %w", name, err)
+		}
+		result = append(result, activation.File{Name: name, File: f})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Detach keep socket files of unix listeners after closing, they are used by the new process after upgrade
+func (v *_listeners) Detach() {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	for _, item := range v.items {
+		if l, ok := item.listener.(*net.UnixListener); ok {
+			l.SetUnlinkOnClose(false)
+		}
+	}
+}
+
+func closeFiles(files []activation.File) []activation.File {
+	for _, f := range files {
+		_ = f.File.Close() // nolint: errcheck
+	}
+	return nil
+}
+
+// Close close all listeners, listeners already closed by services are skipped
+func (v *_listeners) Close() error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	var result error
+	for name, item := range v.items {
+		if err := item.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			result = errors.Wrap(result, fmt.Errorf("close listener [%s]: %w", name, err))
+		}
+		delete(v.items, name)
+	}
+	return result
+}
+
+func listen(conf config.ListenerConfig) (net.Listener, error) {
+	network := conf.Network
+	if len(network) == 0 {
+		network = "tcp"
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "unix":
+		if err := removeStaleSocket(conf.Address); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network [%s]", network)
+	}
+	return net.Listen(network, conf.Address)
+}
+
+// removeStaleSocket remove the socket file of the stopped process,
+// the file is kept if another process accepts connections on it or it is not a socket
+func removeStaleSocket(address string) error {
+	info, err := os.Lstat(address)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	conn, err := net.Dial("unix", address)
+	if err == nil {
+		_ = conn.Close() // nolint: errcheck
+		return fmt.Errorf("socket [%s] is in use by another process", address)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return nil
+	}
+	return os.Remove(address)
+}
@@ -0,0 +1,83 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+	"go.osspkg.com/grape/internal/activation"
+)
+
+func TestUnit_Listeners(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	stale, err := net.Listen("unix", sock)
+	casecheck.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	casecheck.NoError(t, stale.Close())
+
+	inherited, err := net.Listen("tcp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer inherited.Close() //nolint: errcheck
+	file, err := inherited.(*net.TCPListener).File()
+	casecheck.NoError(t, err)
+
+	v := newListeners()
+	casecheck.NoError(t, v.addFiles([]activation.File{{Name: "http", File: file}}, listenerSourceSystemd))
+	casecheck.NoError(t, v.Open(map[string]config.ListenerConfig{
+		"http":  {Address: "127.0.0.1:0"},
+		"admin": {Network: "tcp4", Address: "127.0.0.1:0"},
+		"unix":  {Network: "unix", Address: sock},
+	}))
+	casecheck.Error(t, v.Open(map[string]config.ListenerConfig{"udp": {Network: "udp", Address: "127.0.0.1:0"}}))
+
+	var registry Listeners = v
+	casecheck.Equal(t, []string{"admin", "http", "unix"}, registry.Names())
+
+	l, err := registry.Listener("http")
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, inherited.Addr().String(), l.Addr().String())
+	_, err = registry.Listener("unknown")
+	casecheck.Error(t, err)
+
+	l, err = registry.Listener("admin")
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, l.Close())
+
+	casecheck.NoError(t, v.Close())
+	casecheck.Equal(t, 0, len(registry.Names()))
+	_, err = os.Stat(sock)
+	casecheck.True(t, os.IsNotExist(err))
+}
+
+func TestUnit_ListenersSocketInUse(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "app.sock")
+	active, err := net.Listen("unix", sock)
+	casecheck.NoError(t, err)
+	defer active.Close() //nolint: errcheck
+
+	v := newListeners()
+	casecheck.ErrorContains(t, v.Open(map[string]config.ListenerConfig{
+		"unix": {Network: "unix", Address: sock},
+	}), "in use")
+	_, err = os.Stat(sock)
+	casecheck.NoError(t, err)
+
+	regular := filepath.Join(dir, "file.sock")
+	casecheck.NoError(t, os.WriteFile(regular, []byte("data"), 0600))
+	casecheck.Error(t, v.Open(map[string]config.ListenerConfig{
+		"file": {Network: "unix", Address: regular},
+	}))
+	b, err := os.ReadFile(regular)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "data", string(b))
+	casecheck.NoError(t, v.Close())
+}
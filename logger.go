@@ -6,63 +6,387 @@
 package grape
 
 import (
+	"bytes"
+	"fmt"
 	"io"
-	"log/syslog"
-	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"go.osspkg.com/console"
+	"go.osspkg.com/errors"
 	"go.osspkg.com/grape/config"
 	"go.osspkg.com/logx"
 )
 
-type _log struct {
-	file    io.WriteCloser
-	handler logx.Logger
-	conf    config.LogConfig
-}
+type (
+	// LogLevelController change level of the application logger at runtime
+	LogLevelController interface {
+		Level() uint32
+		SetLevel(level uint32)
+	}
 
-func newLog(tag string, conf config.LogConfig) *_log {
-	var err error
+	// _log the formatter of the logger, lines are filtered by levels of the modules and sinks
+	// and written to every sink in its own format, the logger is left with nothing to write
+	_log struct {
+		sinks   []*_logSink
+		handler logx.Logger
+		conf    config.LogConfig
+		base    uint32
+		modules map[string]uint32
+		named   map[string]*_namedLog
+		// lines count of written lines by level
+		lines  []uint64
+		closed bool
+		mux    sync.RWMutex
+	}
+	_logSink struct {
+		// level own level of the sink, nil - the level of the logger is used
+		level *uint32
+		file  io.WriteCloser
+		// out the async writer of the file or the file
+		out    io.Writer
+		async  *_logAsync
+		format string
+		// native formatter of the logger for string and json formats, the output is the same as without sinks
+		native logx.Formatter
+		encode logEncoder
+		// wrapRaw lines of unknown format are encoded as messages, otherwise they are written as is
+		wrapRaw bool
+	}
+)
+
+func newLog(tag string, conf config.LogConfig) (*_log, error) {
 	object := &_log{
-		conf: conf,
+		conf:    conf,
+		base:    conf.Level,
+		modules: copyModuleLevels(conf.Modules),
+		named:   make(map[string]*_namedLog),
+		lines:   make([]uint64, logx.LevelDebug+1),
 	}
+	for _, sc := range conf.GetSinks() {
+		sink, err := newLogSink(tag, sc)
+		if err != nil {
+			return nil, errors.Wrap(err, object.Close())
+		}
+		object.sinks = append(object.sinks, sink)
+		if !conf.Async.Enabled {
+			continue
+		}
+		if sink.async, err = newLogAsync(sink.file, conf.Async); err != nil {
+			return nil, errors.Wrap(err, object.Close())
+		}
+		sink.out = sink.async
+	}
+	return object, nil
+}
+
+func newLogSink(tag string, conf config.LogSinkConfig) (*_logSink, error) {
+	sink := &_logSink{
+		level:  conf.Level,
+		format: conf.Format,
+		native: nativeLogFormatter(conf.Format),
+		encode: newLogEncoder(conf.Format),
+	}
+
+	var err error
 	switch conf.Format {
-	case "syslog":
-		defer func() {
-			if p := recover(); p != nil {
-				console.Fatalf("logger panic [type=%s filepath=%s]: %v", conf.Format, conf.FilePath, p)
-			}
-		}()
-		network, addr := "", ""
-		if uri, err0 := url.Parse(conf.FilePath); err0 == nil {
-			network, addr = uri.Scheme, uri.Host
+	case logFormatSyslog:
+		var (
+			facility   int
+			severities map[uint32]int
+		)
+		if facility, err = parseSyslogFacility(conf.Syslog.Facility); err != nil {
+			break
+		}
+		if severities, err = parseSyslogSeverities(conf.Syslog.Severities); err != nil {
+			break
 		}
-		object.file, err = syslog.Dial(network, addr, syslog.LOG_INFO, tag)
+		sink.encode = newSyslogEncoder(facility, severities, tag)
+		sink.wrapRaw = true
+		sink.file, err = openSyslog(conf.FilePath, conf.Syslog)
 	default:
-		object.file, err = os.OpenFile(conf.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		sink.file, err = openLogFile(conf.FilePath, conf.Rotate)
 	}
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("open log sink [type=%s filepath=%s]: %w", conf.Format, conf.FilePath, err)
 	}
-	return object
+	sink.out = sink.file
+	return sink, nil
 }
 
+// Handler set the formatter of the logger once, lines of another formatter are written by Write
 func (v *_log) Handler(l logx.Logger) {
 	v.handler = l
-	v.handler.SetOutput(v.file)
-	v.handler.SetLevel(v.conf.Level)
+	v.handler.SetOutput(v)
+	v.handler.SetFormatter(v)
+	v.handler.SetLevel(v.maxLevel())
+}
+
+// maxLevel the most detailed level of all sinks limited by the levels of the logger and modules
+func (v *_log) maxLevel() uint32 {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	limit := v.conf.Level
+	for _, level := range v.modules {
+		if level > limit {
+			limit = level
+		}
+	}
+	var result uint32
+	for _, sink := range v.sinks {
+		if level := sink.Level(limit); level > result {
+			result = level
+		}
+	}
+	if limit < result {
+		result = limit
+	}
+	return result
+}
+
+// Level returns own level of the sink or the level of the logger
+func (v *_logSink) Level(level uint32) uint32 {
+	if v.level != nil {
+		return *v.level
+	}
+	return level
+}
+
+// Encode write the message of the logger call to sinks: the level is taken from the call,
+// string and json sinks use the formatter of the logger, other formats are encoded
+// from the message with sorted fields once for all sinks
+func (v *_log) Encode(m *logx.Message) ([]byte, error) {
+	level, ok := logLevelNames[strings.ToUpper(m.Level)]
+	if !ok {
+		level = logx.LevelInfo
+	}
+	module, _ := m.Ctx[logModuleKey].(string) // nolint: errcheck
+
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	if v.closed {
+		return nil, os.ErrClosed
+	}
+	if level > v.moduleLevel(module) {
+		return nil, nil
+	}
+	v.countLine(level)
+	return nil, v.writeSinks(level, m, nil)
+}
+
+// Write write lines of other formatters: json lines are decoded and encoded again for every sink,
+// it costs a decoding of every line, other lines are written as is
+func (v *_log) Write(p []byte) (int, error) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	if v.closed && len(p) > 0 {
+		return 0, os.ErrClosed
+	}
+
+	var result error
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, ok := parseLogRecord(line)
+		if !ok {
+			for _, sink := range v.sinks {
+				data := withNewLine(line)
+				if sink.wrapRaw {
+					data = sink.encode(newRawLogRecord(line))
+				}
+				if _, err := sink.out.Write(data); err != nil {
+					result = errors.Wrap(result, err)
+				}
+			}
+			continue
+		}
+		if record.Level > v.moduleLevel(record.Module()) {
+			continue
+		}
+		v.countLine(record.Level)
+		result = errors.Wrap(result, v.writeSinks(record.Level, nil, record))
+	}
+	return len(p), result
+}
+
+// writeSinks write the line to sinks with suitable level, the message of the logger call
+// is formatted by native formatters, otherwise the record is encoded
+func (v *_log) writeSinks(level uint32, m *logx.Message, record *logRecord) error {
+	var result error
+	for _, sink := range v.sinks {
+		if sink.level != nil && level > *sink.level {
+			continue
+		}
+		var data []byte
+		if m != nil && sink.native != nil {
+			b, err := sink.native.Encode(m)
+			if err != nil {
+				result = errors.Wrap(result, err)
+				continue
+			}
+			data = b
+		} else {
+			if record == nil {
+				record = newLogRecord(level, m)
+			}
+			data = sink.encode(record)
+		}
+		if _, err := sink.out.Write(data); err != nil {
+			result = errors.Wrap(result, err)
+		}
+	}
+	return result
+}
+
+func (v *_log) countLine(level uint32) {
+	if int(level) < len(v.lines) {
+		atomic.AddUint64(&v.lines[level], 1)
+	}
+}
+
+func (v *_log) Level() uint32 {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	return v.conf.Level
+}
+
+// SetLevel change level of the logger, the change is written to the log
+func (v *_log) SetLevel(level uint32) {
+	if level > logx.LevelDebug {
+		level = logx.LevelDebug
+	}
+	prev := v.Level()
+	if prev == level {
+		return
+	}
+
+	v.mux.Lock()
+	v.conf.Level = level
+	v.mux.Unlock()
+
+	v.handler.SetLevel(v.maxLevel())
+	v.writeNotice("Log level changed", "from", prev, "to", level)
+}
+
+// writeNotice write the info line regardless of the level of the logger,
+// the line is filtered by levels of sinks and written as other lines
+func (v *_log) writeNotice(message string, args ...interface{}) {
+	record := &logRecord{Time: time.Now(), Level: logx.LevelInfo, LvlName: "INFO", Message: message}
+	for i := 0; i+1 < len(args); i += 2 {
+		record.Fields = append(record.Fields, logField{Key: fmt.Sprint(args[i]), Value: args[i+1]})
+	}
+
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	if v.closed {
+		return
+	}
+	v.countLine(record.Level)
+	_ = v.writeSinks(record.Level, nil, record) // nolint: errcheck
+}
+
+// ResetLevel set the level from config, it is the start point of cycling
+func (v *_log) ResetLevel(level uint32) {
+	v.mux.Lock()
+	v.base = level
+	v.mux.Unlock()
+
+	v.SetLevel(level)
+}
 
-	switch v.conf.Format {
-	case "string", "syslog":
-		strFmt := logx.NewFormatString()
-		strFmt.SetDelimiter(' ')
-		v.handler.SetFormatter(strFmt)
-	case "json":
-		v.handler.SetFormatter(logx.NewFormatJSON())
+// CycleLevel raise level by one, after debug level returns to the level from config
+func (v *_log) CycleLevel() {
+	v.mux.RLock()
+	level, base := v.conf.Level, v.base
+	v.mux.RUnlock()
+
+	if level >= logx.LevelDebug {
+		level = base
+	} else {
+		level++
 	}
+	v.SetLevel(level)
 }
 
+// Reopen open the log files again after rotation by external tools
+func (v *_log) Reopen() error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	var result error
+	for _, sink := range v.sinks {
+		if f, ok := sink.file.(interface{ Reopen() error }); ok {
+			result = errors.Wrap(result, f.Reopen())
+		}
+	}
+	return result
+}
+
+// Flush write all lines from queues of the async writers, then lines are written synchronously
+func (v *_log) Flush() {
+	v.mux.RLock()
+	sinks := v.sinks
+	v.mux.RUnlock()
+
+	var dropped uint64
+	for _, sink := range sinks {
+		if sink.async == nil {
+			continue
+		}
+		sink.async.Flush()
+		dropped += sink.async.Dropped()
+	}
+	if dropped > 0 && v.handler != nil {
+		v.handler.Warn("Log lines dropped", "count", dropped)
+	}
+}
+
+// Lines returns count of written lines of the level
+func (v *_log) Lines(level uint32) uint64 {
+	if int(level) >= len(v.lines) {
+		return 0
+	}
+	return atomic.LoadUint64(&v.lines[level])
+}
+
+// Dropped returns count of lines dropped by the async writers and by sinks with buffer
+func (v *_log) Dropped() uint64 {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	var result uint64
+	for _, sink := range v.sinks {
+		if sink.async != nil {
+			result += sink.async.Dropped()
+		}
+		if f, ok := sink.file.(interface{ Dropped() uint64 }); ok {
+			result += f.Dropped()
+		}
+	}
+	return result
+}
+
+// Close write queued lines and close sinks, lines written after closing are dropped
 func (v *_log) Close() error {
-	return v.file.Close()
+	v.Flush()
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.closed {
+		return nil
+	}
+	v.closed = true
+	var result error
+	for _, sink := range v.sinks {
+		result = errors.Wrap(result, sink.file.Close())
+	}
+	return result
 }
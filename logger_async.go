@@ -0,0 +1,97 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.osspkg.com/grape/config"
+)
+
+const (
+	logPolicyBlock   = "block"
+	logPolicyDrop    = "drop"
+	logAsyncQueueDef = 1024
+)
+
+// _logAsync writes lines to the output in background with bounded queue,
+// after flush lines are written synchronously
+type _logAsync struct {
+	out     io.Writer
+	queue   chan []byte
+	drop    bool
+	dropped uint64
+	closed  bool
+	wg      sync.WaitGroup
+	mux     sync.RWMutex
+}
+
+func newLogAsync(out io.Writer, conf config.LogAsyncConfig) (*_logAsync, error) {
+	v := &_logAsync{out: out}
+	switch conf.Policy {
+	case "", logPolicyBlock:
+	case logPolicyDrop:
+		v.drop = true
+	default:
+		return nil, fmt.Errorf("unknown policy of async logger [%s]", conf.Policy)
+	}
+	size := conf.Queue
+	if size <= 0 {
+		size = logAsyncQueueDef
+	}
+	v.queue = make(chan []byte, size)
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		for b := range v.queue {
+			_, _ = v.out.Write(b) // nolint: errcheck
+		}
+	}()
+	return v, nil
+}
+
+func (v *_logAsync) Write(p []byte) (int, error) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+
+	if v.closed {
+		return v.out.Write(p)
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	if !v.drop {
+		v.queue <- b
+		return len(p), nil
+	}
+	select {
+	case v.queue <- b:
+	default:
+		atomic.AddUint64(&v.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns count of lines dropped because of the full queue
+func (v *_logAsync) Dropped() uint64 {
+	return atomic.LoadUint64(&v.dropped)
+}
+
+// Flush wait for writing of all queued lines and switch to synchronous writing
+func (v *_logAsync) Flush() {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.closed {
+		return
+	}
+	v.closed = true
+	close(v.queue)
+	v.wg.Wait()
+}
@@ -0,0 +1,201 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.osspkg.com/errors"
+	"go.osspkg.com/grape/config"
+)
+
+const (
+	logFileMode      = 0666
+	logBackupTimeFmt = "20060102T150405.000000"
+	megabyte         = 1024 * 1024
+)
+
+// _logFile log file with size and time based rotation and reopening for external rotation tools
+type _logFile struct {
+	path     string
+	conf     config.LogRotateConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	rotating bool
+	wg       sync.WaitGroup
+	mux      sync.Mutex
+	bgMux    sync.Mutex
+}
+
+func openLogFile(path string, conf config.LogRotateConfig) (*_logFile, error) {
+	v := &_logFile{
+		path: path,
+		conf: conf,
+	}
+	if err := v.open(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *_logFile) open() error {
+	file, err := os.OpenFile(v.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFileMode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrap(err, file.Close())
+	}
+	v.file = file
+	v.size = info.Size()
+	v.openedAt = time.Now()
+	v.rotating = info.Mode().IsRegular() && (v.conf.MaxSize > 0 || v.conf.Interval > 0)
+	return nil
+}
+
+func (v *_logFile) Write(p []byte) (int, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.file == nil {
+		return 0, os.ErrClosed
+	}
+	if v.needRotate(len(p)) {
+		if err := v.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := v.file.Write(p)
+	v.size += int64(n)
+	return n, err
+}
+
+func (v *_logFile) needRotate(n int) bool {
+	if !v.rotating {
+		return false
+	}
+	if v.conf.MaxSize > 0 && v.size > 0 && v.size+int64(n) > v.conf.MaxSize*megabyte {
+		return true
+	}
+	if v.conf.Interval > 0 && !time.Now().Truncate(v.conf.Interval).Equal(v.openedAt.Truncate(v.conf.Interval)) {
+		return true
+	}
+	return false
+}
+
+func (v *_logFile) rotate() error {
+	if err := v.file.Close(); err != nil {
+		return err
+	}
+	v.file = nil
+
+	backup := v.path + "." + time.Now().Format(logBackupTimeFmt)
+	if err := os.Rename(v.path, backup); err != nil {
+		return errors.Wrap(err, v.open())
+	}
+	if err := v.open(); err != nil {
+		return err
+	}
+
+	v.wg.Add(1)
+	go func() {
+		v.bgMux.Lock()
+		defer func() {
+			v.bgMux.Unlock()
+			v.wg.Done()
+		}()
+		if v.conf.Compress {
+			if err := compressLogFile(backup); err != nil {
+				return
+			}
+		}
+		v.cleanBackups()
+	}()
+	return nil
+}
+
+// Reopen close and open the log file again, used after rotation by external tools
+func (v *_logFile) Reopen() error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.file != nil {
+		if err := v.file.Close(); err != nil {
+			return err
+		}
+		v.file = nil
+	}
+	return v.open()
+}
+
+func (v *_logFile) Close() error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.wg.Wait()
+	if v.file == nil {
+		return nil
+	}
+	err := v.file.Close()
+	v.file = nil
+	return err
+}
+
+func (v *_logFile) cleanBackups() {
+	if v.conf.MaxBackups <= 0 {
+		return
+	}
+	list, err := filepath.Glob(v.path + ".*")
+	if err != nil {
+		return
+	}
+	backups := make([]string, 0, len(list))
+	for _, name := range list {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, v.path+"."), ".gz")
+		if _, err0 := time.Parse(logBackupTimeFmt, suffix); err0 == nil {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= v.conf.MaxBackups {
+		return
+	}
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-v.conf.MaxBackups] {
+		_ = os.Remove(name)
+	}
+}
+
+func compressLogFile(filename string) (err error) {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Wrap(err, src.Close())
+		if err == nil {
+			err = os.Remove(filename)
+		}
+	}()
+
+	dst, err := os.OpenFile(filename+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, logFileMode)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return errors.Wrap(err, gz.Close(), dst.Close())
+	}
+	return errors.Wrap(gz.Close(), dst.Close())
+}
@@ -0,0 +1,64 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+)
+
+func TestUnit_LogFileRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := openLogFile(path, config.LogRotateConfig{MaxSize: 1, MaxBackups: 2, Compress: true})
+	casecheck.NoError(t, err)
+
+	line := append(bytes.Repeat([]byte("a"), megabyte/2-1), '\n')
+	for i := 0; i < 8; i++ {
+		_, err = f.Write(line)
+		casecheck.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	casecheck.NoError(t, f.Close())
+
+	backups, err := filepath.Glob(path + ".*.gz")
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 2, len(backups))
+
+	info, err := os.Stat(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, int64(megabyte), info.Size())
+}
+
+func TestUnit_LogFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := openLogFile(path, config.LogRotateConfig{})
+	casecheck.NoError(t, err)
+	_, err = f.Write([]byte("before\n"))
+	casecheck.NoError(t, err)
+
+	casecheck.NoError(t, os.Rename(path, path+".1"))
+	casecheck.NoError(t, f.Reopen())
+	_, err = f.Write([]byte("after\n"))
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, f.Close())
+
+	b, err := os.ReadFile(path)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "after\n", string(b))
+	b, err = os.ReadFile(path + ".1")
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "before\n", string(b))
+}
@@ -0,0 +1,370 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.osspkg.com/grape/config"
+	"go.osspkg.com/logx"
+)
+
+const (
+	logFormatString = "string"
+	logFormatJSON   = "json"
+	logFormatSyslog = "syslog"
+	logFormatLogfmt = "logfmt"
+	logFormatPretty = "pretty"
+	// logFormatAuto pretty for terminal in DEV env, otherwise string
+	logFormatAuto = "auto"
+
+	logEnvDev = "DEV"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+type (
+	// logRecord one line of the logger decoded from json format
+	logRecord struct {
+		Time    time.Time
+		Level   uint32
+		LvlName string
+		Message string
+		Fields  []logField
+		Raw     []byte
+	}
+	logField struct {
+		Key   string
+		Value interface{}
+	}
+	logEncoder func(r *logRecord) []byte
+)
+
+var logLevelNames = map[string]uint32{
+	"FATAL":   logx.LevelFatal,
+	"ERROR":   logx.LevelError,
+	"WARN":    logx.LevelWarn,
+	"WARNING": logx.LevelWarn,
+	"INFO":    logx.LevelInfo,
+	"DEBUG":   logx.LevelDebug,
+}
+
+// parseLogRecord decode line of json formatter, returns false for lines in unknown format
+func parseLogRecord(line []byte) (*logRecord, bool) {
+	data := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, false
+	}
+
+	r := &logRecord{
+		Level: logx.LevelInfo,
+		Raw:   line,
+	}
+	for key, value := range data {
+		switch key {
+		case "time", "ts":
+			r.Time = parseLogTime(value)
+		case "lvl", "level":
+			r.LvlName = strings.ToUpper(fmt.Sprintf("%v", value))
+			if lvl, ok := logLevelNames[r.LvlName]; ok {
+				r.Level = lvl
+			}
+		case "msg", "message":
+			r.Message = fmt.Sprintf("%v", value)
+		case "ctx":
+			if ctx, ok := value.(map[string]interface{}); ok {
+				for k, v := range ctx {
+					r.Fields = append(r.Fields, logField{Key: k, Value: v})
+				}
+				continue
+			}
+			r.Fields = append(r.Fields, logField{Key: key, Value: value})
+		default:
+			r.Fields = append(r.Fields, logField{Key: key, Value: value})
+		}
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if len(r.LvlName) == 0 {
+		r.LvlName = "INFO"
+	}
+	sort.Slice(r.Fields, func(i, j int) bool { return r.Fields[i].Key < r.Fields[j].Key })
+	return r, true
+}
+
+// newLogRecord record of the logger call with fields sorted by keys, the time is the time of encoding
+func newLogRecord(level uint32, m *logx.Message) *logRecord {
+	r := &logRecord{
+		Time:    time.Now(),
+		Level:   level,
+		LvlName: strings.ToUpper(m.Level),
+		Message: m.Message,
+		Fields:  make([]logField, 0, len(m.Ctx)),
+	}
+	for key, value := range m.Ctx {
+		r.Fields = append(r.Fields, logField{Key: key, Value: value})
+	}
+	sort.Slice(r.Fields, func(i, j int) bool { return r.Fields[i].Key < r.Fields[j].Key })
+	return r
+}
+
+// Module returns name of the module for lines of the named loggers
+func (r *logRecord) Module() string {
+	for _, f := range r.Fields {
+		if f.Key == logModuleKey {
+			if name, ok := f.Value.(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func parseLogTime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			switch {
+			case i > 1e15:
+				return time.UnixMicro(i)
+			case i > 1e12:
+				return time.UnixMilli(i)
+			default:
+				return time.Unix(i, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// nativeLogFormatter returns formatter of the logger for the format, nil if the format is encoded by sinks
+func nativeLogFormatter(format string) logx.Formatter {
+	switch format {
+	case logFormatString, "":
+		f := logx.NewFormatString()
+		f.SetDelimiter(' ')
+		return f
+	case logFormatJSON:
+		return logx.NewFormatJSON()
+	default:
+		return nil
+	}
+}
+
+func newLogEncoder(format string) logEncoder {
+	switch format {
+	case logFormatJSON:
+		return encodeLogJSON
+	case logFormatLogfmt:
+		return encodeLogfmt
+	case logFormatPretty:
+		return encodeLogPretty
+	default:
+		return encodeLogString
+	}
+}
+
+func encodeLogJSON(r *logRecord) []byte {
+	if len(r.Raw) > 0 {
+		return withNewLine(r.Raw)
+	}
+	data := make(map[string]interface{}, 4)
+	data["time"] = r.Time.Format(time.RFC3339Nano)
+	data["lvl"] = r.LvlName
+	data["msg"] = r.Message
+	if len(r.Fields) > 0 {
+		ctx := make(map[string]interface{}, len(r.Fields))
+		for _, f := range r.Fields {
+			ctx[f.Key] = f.Value
+		}
+		data["ctx"] = ctx
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return []byte(r.Message + "\n")
+	}
+	return append(b, '\n')
+}
+
+func encodeLogString(r *logRecord) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(r.Time.Format(time.RFC3339))
+	buf.WriteByte(' ')
+	buf.WriteString(r.LvlName)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	for _, f := range r.Fields {
+		fmt.Fprintf(buf, " %s=%v", f.Key, f.Value)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func withNewLine(b []byte) []byte {
+	result := make([]byte, 0, len(b)+1)
+	return append(append(result, b...), '\n')
+}
+
+// encodeLogfmt encode line in logfmt format: time=... level=info msg="..." key=value
+func encodeLogfmt(r *logRecord) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("time=")
+	buf.WriteString(r.Time.Format(time.RFC3339Nano))
+	buf.WriteString(" level=")
+	buf.WriteString(strings.ToLower(r.LvlName))
+	buf.WriteString(" msg=")
+	buf.WriteString(logfmtValue(r.Message))
+	for _, f := range r.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(f.Key))
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(logValueString(f.Value)))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func logfmtKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+	if len(key) == 0 {
+		return "_"
+	}
+	return key
+}
+
+func logfmtValue(value string) string {
+	if len(value) == 0 {
+		return `""`
+	}
+	if strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0 {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// logValueString nested objects and lists are encoded to json
+func logValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// encodeLogPretty encode line for reading in the terminal with colours
+func encodeLogPretty(r *logRecord) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(colorGray)
+	buf.WriteString(r.Time.Format("15:04:05.000"))
+	buf.WriteString(colorReset)
+	buf.WriteByte(' ')
+	buf.WriteString(logLevelColor(r.Level))
+	fmt.Fprintf(buf, "%-5s", r.LvlName)
+	buf.WriteString(colorReset)
+	if module := r.Module(); len(module) > 0 {
+		buf.WriteString(" [")
+		buf.WriteString(module)
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	for _, f := range r.Fields {
+		if f.Key == logModuleKey {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(colorCyan)
+		buf.WriteString(f.Key)
+		buf.WriteString(colorReset)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(logValueString(f.Value)))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func logLevelColor(level uint32) string {
+	switch level {
+	case logx.LevelFatal, logx.LevelError:
+		return colorRed
+	case logx.LevelWarn:
+		return colorYellow
+	case logx.LevelInfo:
+		return colorGreen
+	default:
+		return colorBlue
+	}
+}
+
+// resolveLogFormat select format of the sinks with auto format:
+// pretty for terminal in DEV env, otherwise string
+func resolveLogFormat(conf config.LogConfig, env string) config.LogConfig {
+	resolve := func(format, path string) string {
+		if format != logFormatAuto && len(format) > 0 {
+			return format
+		}
+		if strings.EqualFold(env, logEnvDev) && isTerminal(path) {
+			return logFormatPretty
+		}
+		return logFormatString
+	}
+	if len(conf.Sinks) == 0 {
+		conf.Format = resolve(conf.Format, conf.FilePath)
+		return conf
+	}
+	sinks := make([]config.LogSinkConfig, 0, len(conf.Sinks))
+	for _, sink := range conf.Sinks {
+		sink.Format = resolve(sink.Format, sink.FilePath)
+		sinks = append(sinks, sink)
+	}
+	conf.Sinks = sinks
+	return conf
+}
+
+func isTerminal(path string) bool {
+	var file *os.File
+	switch path {
+	case "/dev/stdout":
+		file = os.Stdout
+	case "/dev/stderr":
+		file = os.Stderr
+	default:
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,190 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+
+	reflect2 "go.osspkg.com/grape/reflect"
+	"go.osspkg.com/logx"
+)
+
+const logModuleKey = "module"
+
+type (
+	// LoggerFactory creates loggers of the modules, every line is tagged with the module name
+	// and filtered by the level of the module from config
+	LoggerFactory interface {
+		// Named returns logger of the module with the given name
+		Named(name string) logx.Logger
+		// For returns logger of the module, the name is the address of the object type
+		// or the full name of the function
+		For(module interface{}) logx.Logger
+	}
+
+	_namedLog struct {
+		name string
+		log  *_log
+	}
+)
+
+func (v *_log) Named(name string) logx.Logger {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if l, ok := v.named[name]; ok {
+		return l
+	}
+	l := &_namedLog{name: name, log: v}
+	v.named[name] = l
+	return l
+}
+
+func (v *_log) For(module interface{}) logx.Logger {
+	return v.Named(moduleName(module))
+}
+
+// ModuleLevel returns level of the module: own level, level of the nearest parent or the logger
+func (v *_log) ModuleLevel(name string) uint32 {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	return v.moduleLevel(name)
+}
+
+// SetModuleLevel change level of the module at runtime
+func (v *_log) SetModuleLevel(name string, level uint32) {
+	if level > logx.LevelDebug {
+		level = logx.LevelDebug
+	}
+	v.mux.Lock()
+	v.modules[name] = level
+	v.mux.Unlock()
+
+	v.handler.SetLevel(v.maxLevel())
+}
+
+// ResetModuleLevels replace levels of all modules with levels from config
+func (v *_log) ResetModuleLevels(levels map[string]uint32) {
+	v.mux.Lock()
+	v.modules = copyModuleLevels(levels)
+	v.mux.Unlock()
+
+	v.handler.SetLevel(v.maxLevel())
+}
+
+func (v *_log) moduleLevel(name string) uint32 {
+	level, size := v.conf.Level, -1
+	for key, value := range v.modules {
+		if len(key) <= size || !isModuleOf(name, key) {
+			continue
+		}
+		level, size = value, len(key)
+	}
+	return level
+}
+
+// isModuleOf checks the name is the module or the child of the module: db, db.pool, example.com/app/db.*Pool
+func isModuleOf(name, module string) bool {
+	if !strings.HasPrefix(name, module) {
+		return false
+	}
+	if len(name) == len(module) {
+		return true
+	}
+	switch name[len(module)] {
+	case '.', '/':
+		return true
+	default:
+		return false
+	}
+}
+
+func copyModuleLevels(levels map[string]uint32) map[string]uint32 {
+	result := make(map[string]uint32, len(levels))
+	for key, value := range levels {
+		if value > logx.LevelDebug {
+			value = logx.LevelDebug
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func moduleName(module interface{}) string {
+	switch v := module.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	ref := reflect.TypeOf(module)
+	if ref.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(reflect.ValueOf(module).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	name, _ := reflect2.GetAddress(ref, module)
+	return strings.TrimLeft(name, "*")
+}
+
+// SetOutput the output is common for all modules and is changed only by the application
+func (v *_namedLog) SetOutput(_ io.Writer) {}
+
+// SetFormatter the format is common for all modules and is changed only by the application
+func (v *_namedLog) SetFormatter(_ logx.Formatter) {}
+
+func (v *_namedLog) SetLevel(level uint32) {
+	v.log.SetModuleLevel(v.name, level)
+}
+
+func (v *_namedLog) GetLevel() uint32 {
+	return v.log.ModuleLevel(v.name)
+}
+
+// Close the output is closed by the application
+func (v *_namedLog) Close() {}
+
+func (v *_namedLog) Fatal(message string, args ...interface{}) {
+	if v.enabled(logx.LevelFatal) {
+		v.log.handler.Fatal(message, v.args(args)...)
+	}
+}
+
+func (v *_namedLog) Error(message string, args ...interface{}) {
+	if v.enabled(logx.LevelError) {
+		v.log.handler.Error(message, v.args(args)...)
+	}
+}
+
+func (v *_namedLog) Warn(message string, args ...interface{}) {
+	if v.enabled(logx.LevelWarn) {
+		v.log.handler.Warn(message, v.args(args)...)
+	}
+}
+
+func (v *_namedLog) Info(message string, args ...interface{}) {
+	if v.enabled(logx.LevelInfo) {
+		v.log.handler.Info(message, v.args(args)...)
+	}
+}
+
+func (v *_namedLog) Debug(message string, args ...interface{}) {
+	if v.enabled(logx.LevelDebug) {
+		v.log.handler.Debug(message, v.args(args)...)
+	}
+}
+
+func (v *_namedLog) enabled(level uint32) bool {
+	return level <= v.log.ModuleLevel(v.name)
+}
+
+func (v *_namedLog) args(args []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(args)+2)
+	return append(append(result, logModuleKey, v.name), args...)
+}
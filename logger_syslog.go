@@ -0,0 +1,210 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.osspkg.com/grape/config"
+	"go.osspkg.com/grape/internal/syslog"
+	"go.osspkg.com/logx"
+)
+
+const (
+	syslogTimeFmt = "2006-01-02T15:04:05.000000Z07:00"
+	// syslogSDID id of the structured data with fields of the log line,
+	// 32473 is the enterprise number reserved for documentation (RFC 5612)
+	syslogSDID = "fields@32473"
+	// syslogNil empty value of the header field
+	syslogNil = "-"
+
+	syslogMaxHostname = 255
+	syslogMaxAppName  = 48
+	syslogMaxParam    = 32
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogSeverities default severity of the log levels: crit, err, warning, info, debug
+var syslogSeverities = map[uint32]int{
+	logx.LevelFatal: 2,
+	logx.LevelError: 3,
+	logx.LevelWarn:  4,
+	logx.LevelInfo:  6,
+	logx.LevelDebug: 7,
+}
+
+var syslogSeverityNames = map[string]int{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+var syslogLevelNames = map[string]uint32{
+	"fatal": logx.LevelFatal,
+	"error": logx.LevelError,
+	"warn":  logx.LevelWarn,
+	"info":  logx.LevelInfo,
+	"debug": logx.LevelDebug,
+}
+
+func openSyslog(path string, conf config.LogSyslogConfig) (*syslog.Writer, error) {
+	network, addr, err := syslog.ParseAddress(path)
+	if err != nil {
+		return nil, err
+	}
+	return syslog.New(syslog.Config{
+		Network: network,
+		Address: addr,
+		Buffer:  conf.Buffer,
+		Timeout: conf.Timeout,
+	})
+}
+
+func parseSyslogFacility(name string) (int, error) {
+	if len(name) == 0 {
+		return syslogFacilities["user"], nil
+	}
+	if facility, ok := syslogFacilities[strings.ToLower(name)]; ok {
+		return facility, nil
+	}
+	return 0, fmt.Errorf("unknown syslog facility [%s]", name)
+}
+
+// parseSyslogSeverities override the default severities of the log levels from config
+func parseSyslogSeverities(names map[string]string) (map[uint32]int, error) {
+	result := make(map[uint32]int, len(syslogSeverities))
+	for level, severity := range syslogSeverities {
+		result[level] = severity
+	}
+	for levelName, severityName := range names {
+		level, ok := syslogLevelNames[strings.ToLower(levelName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown log level [%s] in syslog severities", levelName)
+		}
+		severity, ok := syslogSeverityNames[strings.ToLower(severityName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog severity [%s]", severityName)
+		}
+		result[level] = severity
+	}
+	return result, nil
+}
+
+// newSyslogEncoder encode line of the logger to RFC 5424 message, fields are written as structured data
+func newSyslogEncoder(facility int, severities map[uint32]int, tag string) logEncoder {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+	hostname = syslogHeader(hostname, syslogMaxHostname)
+	appName := syslogHeader(tag, syslogMaxAppName)
+	procID := strconv.Itoa(os.Getpid())
+
+	return func(r *logRecord) []byte {
+		severity, ok := severities[r.Level]
+		if !ok {
+			severity = severities[logx.LevelInfo]
+		}
+
+		buf := &bytes.Buffer{}
+		fmt.Fprintf(buf, "<%d>1 %s %s %s %s %s ",
+			facility*8+severity, r.Time.Format(syslogTimeFmt), hostname, appName, procID, syslogNil)
+		if len(r.Fields) == 0 {
+			buf.WriteString(syslogNil)
+		} else {
+			buf.WriteString("[" + syslogSDID)
+			for _, f := range r.Fields {
+				fmt.Fprintf(buf, " %s=\"%s\"", syslogParamName(f.Key), syslogParamValue(f.Value))
+			}
+			buf.WriteByte(']')
+		}
+		if len(r.Message) > 0 {
+			buf.WriteByte(' ')
+			buf.WriteString(r.Message)
+		}
+		return buf.Bytes()
+	}
+}
+
+// newRawLogRecord wrap line of unknown format to the record
+func newRawLogRecord(line []byte) *logRecord {
+	return &logRecord{
+		Time:    time.Now(),
+		Level:   logx.LevelInfo,
+		LvlName: "INFO",
+		Message: string(line),
+		Raw:     line,
+	}
+}
+
+// syslogHeader header field contains only printable US-ASCII without spaces
+func syslogHeader(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	if len(value) == 0 {
+		return syslogNil
+	}
+	return value
+}
+
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > syslogMaxParam {
+		name = name[:syslogMaxParam]
+	}
+	if len(name) == 0 {
+		return "_"
+	}
+	return name
+}
+
+func syslogParamValue(value interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(fmt.Sprintf("%v", value))
+}
@@ -0,0 +1,351 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/config"
+	"go.osspkg.com/logx"
+)
+
+func TestUnit_LogSinks(t *testing.T) {
+	dir := t.TempDir()
+	errLevel := logx.LevelError
+	conf := config.LogConfig{
+		Level: logx.LevelInfo,
+		Sinks: []config.LogSinkConfig{
+			{FilePath: filepath.Join(dir, "all.log"), Format: "string"},
+			{FilePath: filepath.Join(dir, "err.log"), Format: "json", Level: &errLevel},
+		},
+	}
+	l, err := newLog("test", conf)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, logx.LevelInfo, l.maxLevel())
+
+	lines := `{"time":"2024-01-02T03:04:05Z","lvl":"INFO","msg":"started","ctx":{"port":80}}
+{"time":"2024-01-02T03:04:06Z","lvl":"ERROR","msg":"failed","ctx":{"err":"timeout"}}
+{"time":"2024-01-02T03:04:07Z","lvl":"DEBUG","msg":"details"}
+not json line
+`
+	_, err = l.Write([]byte(lines))
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, l.Close())
+
+	b, err := os.ReadFile(filepath.Join(dir, "all.log"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "2024-01-02T03:04:05Z INFO started port=80\n"+
+		"2024-01-02T03:04:06Z ERROR failed err=timeout\n"+
+		"not json line\n", string(b))
+
+	b, err = os.ReadFile(filepath.Join(dir, "err.log"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, `{"time":"2024-01-02T03:04:06Z","lvl":"ERROR","msg":"failed","ctx":{"err":"timeout"}}`+"\n"+
+		"not json line\n", string(b))
+}
+
+func TestUnit_LogLevelController(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	l, err := newLog("test", config.LogConfig{Level: logx.LevelWarn, FilePath: filename, Format: "json"})
+	casecheck.NoError(t, err)
+	l.Handler(logx.Default())
+
+	var ctrl LogLevelController = l
+	casecheck.Equal(t, logx.LevelWarn, ctrl.Level())
+
+	l.CycleLevel()
+	casecheck.Equal(t, logx.LevelInfo, ctrl.Level())
+	l.CycleLevel()
+	casecheck.Equal(t, logx.LevelDebug, ctrl.Level())
+	l.CycleLevel()
+	casecheck.Equal(t, logx.LevelWarn, ctrl.Level())
+
+	// warn -> error: both levels are below info
+	ctrl.SetLevel(logx.LevelError)
+	casecheck.Equal(t, logx.LevelError, ctrl.Level())
+	casecheck.NoError(t, l.Close())
+
+	b, err := os.ReadFile(filename)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 4, strings.Count(string(b), "Log level changed"))
+	casecheck.True(t, strings.Contains(string(b), `"from":2,"to":1`))
+}
+
+func TestUnit_LogFormatter(t *testing.T) {
+	dir := t.TempDir()
+	strFile, fmtFile := filepath.Join(dir, "app.log"), filepath.Join(dir, "app.logfmt")
+	l, err := newLog("test", config.LogConfig{
+		Level: logx.LevelInfo,
+		Sinks: []config.LogSinkConfig{
+			{FilePath: strFile, Format: "string"},
+			{FilePath: fmtFile, Format: "logfmt"},
+		},
+	})
+	casecheck.NoError(t, err)
+	l.Handler(logx.New())
+
+	// the string sink is formatted by the logger as before sinks
+	baseline := &bytes.Buffer{}
+	base := logx.New()
+	base.SetOutput(baseline)
+	strFmt := logx.NewFormatString()
+	strFmt.SetDelimiter(' ')
+	base.SetFormatter(strFmt)
+	base.SetLevel(logx.LevelInfo)
+	for i := 0; i < 3; i++ {
+		baseline.Reset()
+		casecheck.NoError(t, os.Truncate(strFile, 0))
+		l.handler.Info("message", "key", "value")
+		base.Info("message", "key", "value")
+		b, err0 := os.ReadFile(strFile)
+		casecheck.NoError(t, err0)
+		if string(b) == baseline.String() {
+			break
+		}
+		casecheck.True(t, i < 2, string(b), baseline.String())
+	}
+
+	// the module level is changed while other goroutines are writing
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Named("http").Info("request")
+			}
+		}()
+	}
+	l.SetModuleLevel("db", logx.LevelDebug)
+	wg.Wait()
+	casecheck.Equal(t, logx.LevelDebug, l.handler.GetLevel())
+
+	l.Named("db").Debug("query")
+	l.handler.Debug("filtered")
+	l.ResetModuleLevels(nil)
+	l.SetLevel(logx.LevelError)
+	casecheck.NoError(t, l.Close())
+	l.handler.Error("after close")
+
+	b, err := os.ReadFile(fmtFile)
+	casecheck.NoError(t, err)
+	out := string(b)
+	casecheck.Equal(t, 400, strings.Count(out, "msg=request module=http\n"))
+	casecheck.True(t, strings.Contains(out, "level=debug msg=query module=db\n"))
+	casecheck.True(t, strings.Contains(out, `level=info msg="Log level changed" from=3 to=1`))
+	casecheck.False(t, strings.Contains(out, "filtered"))
+	casecheck.False(t, strings.Contains(out, "after close"))
+	casecheck.Equal(t, uint64(1), l.Lines(logx.LevelDebug))
+	casecheck.Equal(t, uint64(401+strings.Count(out, "msg=message")), l.Lines(logx.LevelInfo))
+}
+
+type testLogModule struct{}
+
+func TestUnit_LogNamed(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	l, err := newLog("test", config.LogConfig{
+		Level:    logx.LevelInfo,
+		FilePath: filename,
+		Format:   "json",
+		Modules:  map[string]uint32{"db": logx.LevelDebug, "http": logx.LevelError},
+	})
+	casecheck.NoError(t, err)
+	l.Handler(logx.New())
+	casecheck.Equal(t, logx.LevelDebug, l.maxLevel())
+
+	var factory LoggerFactory = l
+	casecheck.True(t, factory.Named("db") == factory.Named("db"))
+
+	l.handler.Debug("root debug")
+	factory.Named("db").Debug("db debug")
+	factory.Named("db.pool").Debug("pool debug", "size", 1)
+	factory.Named("http").Info("http info")
+	factory.Named("http").Error("http error")
+	factory.Named("dbx").Debug("dbx debug")
+
+	cache := factory.For(&testLogModule{})
+	casecheck.Equal(t, logx.LevelInfo, cache.GetLevel())
+	cache.SetLevel(logx.LevelWarn)
+	cache.Info("cache info")
+	cache.Warn("cache warn")
+
+	l.ResetModuleLevels(nil)
+	casecheck.Equal(t, logx.LevelInfo, l.maxLevel())
+	factory.Named("db").Debug("db debug after reset")
+	casecheck.NoError(t, l.Close())
+
+	out := readLogLines(t, filename)
+	casecheck.False(t, strings.Contains(out, "root debug"))
+	casecheck.True(t, strings.Contains(out, "DEBUG db debug module=db\n"))
+	casecheck.True(t, strings.Contains(out, "DEBUG pool debug module=db.pool size=1\n"))
+	casecheck.False(t, strings.Contains(out, "http info"))
+	casecheck.True(t, strings.Contains(out, "ERROR http error module=http\n"))
+	casecheck.False(t, strings.Contains(out, "dbx debug"))
+	casecheck.False(t, strings.Contains(out, "cache info"))
+	casecheck.True(t, strings.Contains(out, "WARN cache warn module=go.osspkg.com/grape.testLogModule\n"))
+	casecheck.False(t, strings.Contains(out, "after reset"))
+}
+
+// readLogLines decode json lines of the log file to strings without time: LEVEL message key=value
+func readLogLines(t *testing.T, filename string) string {
+	b, err := os.ReadFile(filename)
+	casecheck.NoError(t, err)
+	buf := &strings.Builder{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		record, ok := parseLogRecord([]byte(line))
+		casecheck.True(t, ok, line)
+		buf.WriteString(record.LvlName + " " + record.Message)
+		for _, f := range record.Fields {
+			fmt.Fprintf(buf, " %s=%v", f.Key, f.Value)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+func TestUnit_LogSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	casecheck.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+
+	l, err := newLog("test app", config.LogConfig{
+		Level:    logx.LevelInfo,
+		FilePath: "udp://" + conn.LocalAddr().String(),
+		Format:   "syslog",
+		Syslog:   config.LogSyslogConfig{Facility: "local0", Severities: map[string]string{"error": "alert"}},
+	})
+	casecheck.NoError(t, err)
+
+	_, err = l.Write([]byte(`{"time":"2024-01-02T03:04:05Z","lvl":"ERROR","msg":"failed","ctx":{"err":"a \"b\"]","port":80}}
+not json line
+`))
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, l.Close())
+
+	hostname, _ := os.Hostname()
+	prefix := "<129>1 2024-01-02T03:04:05.000000Z " + syslogHeader(hostname, syslogMaxHostname) +
+		" test_app " + strconv.Itoa(os.Getpid()) + " - "
+
+	casecheck.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, prefix+`[fields@32473 err="a \"b\"\]" port="80"] failed`, string(buf[:n]))
+
+	n, _, err = conn.ReadFrom(buf)
+	casecheck.NoError(t, err)
+	casecheck.True(t, strings.HasPrefix(string(buf[:n]), "<134>1 "))
+	casecheck.True(t, strings.HasSuffix(string(buf[:n]), " test_app "+strconv.Itoa(os.Getpid())+" - - not json line"))
+
+	_, err = newLog("test", config.LogConfig{Format: "syslog", Syslog: config.LogSyslogConfig{Facility: "unknown"}})
+	casecheck.Error(t, err)
+
+	// the file path of the default config is replaced with the local syslog daemon
+	conf := config.Default().Log
+	conf.Format = "syslog"
+	local, err := newLog("test", conf)
+	casecheck.NoError(t, err)
+	casecheck.NoError(t, local.Close())
+	_, err = newLog("test", config.LogConfig{Format: "syslog", Syslog: config.LogSyslogConfig{Severities: map[string]string{"error": "fatal"}}})
+	casecheck.Error(t, err)
+}
+
+type testSlowWriter struct {
+	release chan struct{}
+	lines   []string
+	mux     sync.Mutex
+}
+
+func (v *testSlowWriter) Write(p []byte) (int, error) {
+	<-v.release
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	v.lines = append(v.lines, string(p))
+	return len(p), nil
+}
+
+func TestUnit_LogAsync(t *testing.T) {
+	out := &testSlowWriter{release: make(chan struct{})}
+	w, err := newLogAsync(out, config.LogAsyncConfig{Queue: 2, Policy: "drop"})
+	casecheck.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = w.Write([]byte("line " + strconv.Itoa(i)))
+		casecheck.NoError(t, err)
+	}
+	close(out.release)
+	w.Flush()
+
+	dropped := int(w.Dropped())
+	casecheck.True(t, dropped >= 2 && dropped <= 3)
+	casecheck.Equal(t, 5-dropped, len(out.lines))
+	casecheck.Equal(t, "line 0", out.lines[0])
+
+	_, err = w.Write([]byte("after flush"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, "after flush", out.lines[len(out.lines)-1])
+
+	_, err = newLogAsync(out, config.LogAsyncConfig{Policy: "unknown"})
+	casecheck.Error(t, err)
+}
+
+func TestUnit_LogAsyncFlush(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	l, err := newLog("test", config.LogConfig{
+		Level:    logx.LevelInfo,
+		FilePath: filename,
+		Format:   "string",
+		Async:    config.LogAsyncConfig{Enabled: true, Queue: 4},
+	})
+	casecheck.NoError(t, err)
+	l.Handler(logx.New())
+
+	for i := 0; i < 100; i++ {
+		l.handler.Info("message", "n", i)
+	}
+	l.Flush()
+	casecheck.NoError(t, l.Close())
+	casecheck.Equal(t, uint64(0), l.Dropped())
+
+	b, err := os.ReadFile(filename)
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 100, strings.Count(string(b), "message"))
+	casecheck.Equal(t, uint64(100), l.Lines(logx.LevelInfo))
+}
+
+func TestUnit_LogFormats(t *testing.T) {
+	record, ok := parseLogRecord([]byte(`{"time":"2024-01-02T03:04:05Z","lvl":"WARN","msg":"slow query",` +
+		`"ctx":{"module":"db","sql":"select 1","ms":150,"tags":["a","b"],"empty":""}}`))
+	casecheck.True(t, ok)
+
+	casecheck.Equal(t, `time=2024-01-02T03:04:05Z level=warn msg="slow query" empty="" module=db ms=150 `+
+		`sql="select 1" tags="[\"a\",\"b\"]"`+"\n", string(encodeLogfmt(record)))
+
+	casecheck.Equal(t, "\033[90m03:04:05.000\033[0m \033[33mWARN \033[0m [db] slow query "+
+		"\033[36mempty\033[0m=\"\" \033[36mms\033[0m=150 \033[36msql\033[0m=\"select 1\" \033[36mtags\033[0m=\"[\\\"a\\\",\\\"b\\\"]\"\n",
+		string(encodeLogPretty(record)))
+
+	conf := resolveLogFormat(config.LogConfig{Format: "auto", FilePath: filepath.Join(t.TempDir(), "app.log")}, "DEV")
+	casecheck.Equal(t, "string", conf.Format)
+	conf = resolveLogFormat(config.LogConfig{Sinks: []config.LogSinkConfig{
+		{Format: "", FilePath: "/dev/null"},
+		{Format: "logfmt", FilePath: "/dev/stdout"},
+	}}, "DEV")
+	casecheck.Equal(t, "string", conf.Sinks[0].Format)
+	casecheck.Equal(t, "logfmt", conf.Sinks[1].Format)
+}
@@ -0,0 +1,103 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"go.osspkg.com/grape/container"
+	"go.osspkg.com/grape/lifecycle"
+	"go.osspkg.com/grape/metrics"
+	"go.osspkg.com/grape/services"
+	"go.osspkg.com/logx"
+)
+
+var logLevelLabels = []struct {
+	level uint32
+	label string
+}{
+	{logx.LevelFatal, "fatal"},
+	{logx.LevelError, "error"},
+	{logx.LevelWarn, "warn"},
+	{logx.LevelInfo, "info"},
+	{logx.LevelDebug, "debug"},
+}
+
+// registerMetrics add metrics of the application lifecycle to the registry,
+// values are calculated on scrape from the state of the application
+func (a *_grape) registerMetrics() {
+	r := a.metrics
+	r.Collect("grape_build_info", "Build information of the application.", metrics.TypeGauge,
+		[]string{"app", "version", "go_version"}, func() []metrics.Sample {
+			version := "unknown"
+			if info, ok := debug.ReadBuildInfo(); ok && len(info.Main.Version) > 0 {
+				version = info.Main.Version
+			}
+			return []metrics.Sample{{Labels: []string{a.appName, version, runtime.Version()}, Value: 1}}
+		})
+	a.startupDuration = r.Gauge("grape_startup_duration_seconds", "Duration of the application startup.")
+	r.Collect("grape_restarts_total", "Count of upgrades of the application since the first start.",
+		metrics.TypeCounter, nil, func() []metrics.Sample {
+			return []metrics.Sample{{Value: float64(a.restarts)}}
+		})
+	// services of the same type differ by the index in order of starting
+	r.Collect("grape_service_up", "Service is running.", metrics.TypeGauge,
+		[]string{"service", "index"}, func() []metrics.Sample {
+			states := a.packages.States()
+			result := make([]metrics.Sample, 0, len(states))
+			for i, s := range states {
+				value := 0.0
+				if s.State == services.StateRunning {
+					value = 1
+				}
+				result = append(result, metrics.Sample{Labels: []string{s.Name, strconv.Itoa(i)}, Value: value})
+			}
+			return result
+		})
+	r.Collect("grape_constructor_duration_seconds", "Duration of the constructor call during startup.",
+		metrics.TypeGauge, []string{"constructor"}, func() []metrics.Sample {
+			result := make([]metrics.Sample, 0)
+			for _, t := range a.packages.Timings() {
+				if t.Stage == container.StageConstructor {
+					result = append(result, metrics.Sample{Labels: []string{t.Name}, Value: t.Duration.Seconds()})
+				}
+			}
+			return result
+		})
+	r.Collect("grape_log_lines_total", "Count of written log lines by level.", metrics.TypeCounter,
+		[]string{"level"}, func() []metrics.Sample {
+			if a.logHandler == nil {
+				return nil
+			}
+			result := make([]metrics.Sample, 0, len(logLevelLabels))
+			for _, l := range logLevelLabels {
+				result = append(result, metrics.Sample{Labels: []string{l.label}, Value: float64(a.logHandler.Lines(l.level))})
+			}
+			return result
+		})
+
+	reloads := r.Counter("grape_config_reloads_total", "Count of config reloads by result.", "result")
+	reloads.Add(0, "success")
+	reloads.Add(0, "failure")
+	a.events.Observe(lifecycle.ObserverFunc(func(e lifecycle.Event) {
+		if e.Kind != lifecycle.ConfigReloaded {
+			return
+		}
+		if e.Err != nil {
+			reloads.Inc("failure")
+			return
+		}
+		reloads.Inc("success")
+	}))
+}
+
+// observeStartup set duration of startup since the application is run
+func (a *_grape) observeStartup(started time.Time) {
+	a.startupDuration.Set(time.Since(started).Seconds())
+}
@@ -0,0 +1,283 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeCounter = "counter"
+	TypeGauge   = "gauge"
+
+	// ContentType content type of the Prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	rexName  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	rexLabel = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type (
+	// Registry metrics in Prometheus text exposition format, the registry is the http handler
+	// which can be mounted on the admin server. Metrics are created once by name, the second call
+	// with the same name, type and labels returns the existing metric, otherwise it panics
+	Registry interface {
+		Counter(name, help string, labels ...string) Counter
+		Gauge(name, help string, labels ...string) Gauge
+		// Collect add metric with values calculated on every scrape
+		Collect(name, help, typ string, labels []string, collect func() []Sample)
+		WriteTo(w io.Writer) (int64, error)
+		ServeHTTP(w http.ResponseWriter, r *http.Request)
+	}
+
+	// Counter monotonically increasing value, label values are passed in order of label names
+	Counter interface {
+		Inc(labelValues ...string)
+		Add(delta float64, labelValues ...string)
+	}
+
+	// Gauge value which can go up and down, label values are passed in order of label names
+	Gauge interface {
+		Set(value float64, labelValues ...string)
+		Add(delta float64, labelValues ...string)
+	}
+
+	// Sample value of the collected metric with label values in order of label names
+	Sample struct {
+		Labels []string
+		Value  float64
+	}
+
+	_registry struct {
+		families map[string]*_family
+		mux      sync.RWMutex
+	}
+	_family struct {
+		name    string
+		help    string
+		typ     string
+		labels  []string
+		values  map[string]*_value
+		collect func() []Sample
+		mux     sync.Mutex
+	}
+	_value struct {
+		labels []string
+		value  float64
+	}
+)
+
+func NewRegistry() Registry {
+	return &_registry{
+		families: make(map[string]*_family),
+	}
+}
+
+func (v *_registry) Counter(name, help string, labels ...string) Counter {
+	return v.family(name, help, TypeCounter, labels, nil)
+}
+
+func (v *_registry) Gauge(name, help string, labels ...string) Gauge {
+	return v.family(name, help, TypeGauge, labels, nil)
+}
+
+func (v *_registry) Collect(name, help, typ string, labels []string, collect func() []Sample) {
+	v.family(name, help, typ, labels, collect)
+}
+
+func (v *_registry) family(name, help, typ string, labels []string, collect func() []Sample) *_family {
+	if !rexName.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name [%s]", name))
+	}
+	for _, label := range labels {
+		if !rexLabel.MatchString(label) || strings.HasPrefix(label, "__") {
+			panic(fmt.Sprintf("metrics: invalid label name [%s] of metric [%s]", label, name))
+		}
+	}
+	if typ != TypeCounter && typ != TypeGauge {
+		panic(fmt.Sprintf("metrics: unsupported type [%s] of metric [%s]", typ, name))
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if f, ok := v.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labels, ",") != strings.Join(labels, ",") ||
+			f.collect != nil || collect != nil {
+			panic(fmt.Sprintf("metrics: metric [%s] is already registered", name))
+		}
+		return f
+	}
+	f := &_family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  append([]string{}, labels...),
+		values:  make(map[string]*_value),
+		collect: collect,
+	}
+	v.families[name] = f
+	return f
+}
+
+func (v *_registry) WriteTo(w io.Writer) (int64, error) {
+	v.mux.RLock()
+	list := make([]*_family, 0, len(v.families))
+	for _, f := range v.families {
+		list = append(list, f)
+	}
+	v.mux.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, f := range list {
+		f.writeTo(cw)
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (v *_registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = v.WriteTo(w) // nolint: errcheck
+}
+
+/**********************************************************************************************************************/
+
+func (f *_family) Inc(labelValues ...string) {
+	f.Add(1, labelValues...)
+}
+
+func (f *_family) Add(delta float64, labelValues ...string) {
+	if f.typ == TypeCounter && delta < 0 {
+		panic(fmt.Sprintf("metrics: counter [%s] can not decrease", f.name))
+	}
+	f.update(labelValues, func(value *_value) { value.value += delta })
+}
+
+func (f *_family) Set(value float64, labelValues ...string) {
+	f.update(labelValues, func(v *_value) { v.value = value })
+}
+
+func (f *_family) update(labelValues []string, call func(v *_value)) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: metric [%s] has %d labels, got %d values", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	value, ok := f.values[key]
+	if !ok {
+		value = &_value{labels: append([]string{}, labelValues...)}
+		f.values[key] = value
+	}
+	call(value)
+}
+
+func (f *_family) samples() []Sample {
+	if f.collect != nil {
+		return f.collect()
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	result := make([]Sample, 0, len(f.values))
+	for _, value := range f.values {
+		result = append(result, Sample{Labels: value.labels, Value: value.value})
+	}
+	return result
+}
+
+func (f *_family) writeTo(w *countWriter) {
+	samples := f.samples()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.typ)
+	for _, s := range samples {
+		if len(s.Labels) != len(f.labels) {
+			continue
+		}
+		w.printf("%s%s %s\n", f.name, formatLabels(f.labels, s.Labels), formatValue(s.Value))
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(values[i]))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (v *countWriter) printf(format string, args ...interface{}) {
+	if v.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(v.w, format, args...)
+	v.n += int64(n)
+	v.err = err
+}
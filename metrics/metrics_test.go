@@ -0,0 +1,64 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package metrics_test
+
+import (
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/metrics"
+)
+
+func TestUnit_Registry(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.Counter("app_requests_total", "Count of requests.\nBy method", "method")
+	c.Inc("GET")
+	c.Add(2, "GET")
+	c.Inc(`PO"ST`)
+	casecheck.True(t, c == r.Counter("app_requests_total", "", "method"))
+
+	g := r.Gauge("app_temperature", "Temperature")
+	g.Set(1.5)
+	g.Add(-2)
+	r.Collect("app_items", "Items", metrics.TypeGauge, []string{"kind"}, func() []metrics.Sample {
+		return []metrics.Sample{{Labels: []string{"b"}, Value: math.Inf(1)}, {Labels: []string{"a"}, Value: 1e-3}}
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	casecheck.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+	casecheck.Equal(t, `# HELP app_items Items
+# TYPE app_items gauge
+app_items{kind="a"} 0.001
+app_items{kind="b"} +Inf
+# HELP app_requests_total Count of requests.\nBy method
+# TYPE app_requests_total counter
+app_requests_total{method="GET"} 3
+app_requests_total{method="PO\"ST"} 1
+# HELP app_temperature Temperature
+# TYPE app_temperature gauge
+app_temperature -0.5
+`, rec.Body.String())
+
+	func() {
+		defer func() { casecheck.NotNil(t, recover()) }()
+		r.Gauge("app_requests_total", "")
+	}()
+	func() {
+		defer func() { casecheck.NotNil(t, recover()) }()
+		c.Inc()
+	}()
+	func() {
+		defer func() { casecheck.NotNil(t, recover()) }()
+		c.Add(-1, "GET")
+	}()
+	func() {
+		defer func() { casecheck.NotNil(t, recover()) }()
+		r.Counter("1bad", "")
+	}()
+}
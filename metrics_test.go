@@ -0,0 +1,54 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/lifecycle"
+)
+
+func TestUnit_Metrics(t *testing.T) {
+	a := newTestApp(t)
+	casecheck.NoError(t, a.packages.Register(newTestTimingService))
+	casecheck.NoError(t, a.packages.Start())
+	a.observeStartup(time.Now().Add(-time.Second))
+	a.log.Info("message")
+	a.log.Debug("filtered")
+	a.logHandler.Flush()
+	lifecycle.Emit(a.events, lifecycle.ConfigReloaded, "test", time.Now(), nil)
+	lifecycle.Emit(a.events, lifecycle.ConfigReloaded, "test", time.Now(), fmt.Errorf("fail"))
+	lifecycle.Emit(a.events, lifecycle.ConfigReloaded, "test", time.Now(), nil)
+
+	buf := &bytes.Buffer{}
+	_, err := a.metrics.WriteTo(buf)
+	casecheck.NoError(t, err)
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE grape_build_info gauge\ngrape_build_info{app=\"test\",",
+		"grape_service_up{service=\"*grape.testTimingService\",index=\"0\"} 1\n",
+		"grape_restarts_total 0\n",
+		"grape_log_lines_total{level=\"info\"} 1\n",
+		"grape_log_lines_total{level=\"debug\"} 0\n",
+		"grape_config_reloads_total{result=\"success\"} 2\n",
+		"grape_config_reloads_total{result=\"failure\"} 1\n",
+		"grape_constructor_duration_seconds{constructor=\"go.osspkg.com/grape.newTestTimingService func() *grape.testTimingService\"} ",
+		"grape_startup_duration_seconds 1",
+	} {
+		casecheck.True(t, strings.Contains(out, line), line)
+	}
+
+	casecheck.NoError(t, a.packages.Stop())
+	buf.Reset()
+	_, err = a.metrics.WriteTo(buf)
+	casecheck.NoError(t, err)
+	casecheck.True(t, strings.Contains(buf.String(), "grape_service_up{service=\"*grape.testTimingService\",index=\"0\"} 0\n"))
+}
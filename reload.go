@@ -0,0 +1,143 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	reflect2 "reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.osspkg.com/errors"
+	config2 "go.osspkg.com/grape/config"
+	"go.osspkg.com/grape/internal/sdnotify"
+	"go.osspkg.com/grape/lifecycle"
+	"go.osspkg.com/grape/reflect"
+)
+
+// reloadConfig read config files again and send new config models to subscribers,
+// the current config is kept if reading or validation fails
+func (a *_grape) reloadConfig() error {
+	doc, err := a.readConfig()
+	if err != nil {
+		return err
+	}
+
+	appConfig := config2.Default()
+	if err = a.decodeConfig(doc, appConfig); err != nil {
+		return err
+	}
+	validateErr := config2.Validate(appConfig)
+
+	configs, err := reflect.TypingPtr(copyModels(a.configTemplates), func(c interface{}) error {
+		if err0 := a.decodeConfig(doc, c); err0 != nil {
+			return err0
+		}
+		validateErr = errors.Wrap(validateErr, config2.Validate(c))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if validateErr != nil {
+		return validateErr
+	}
+	if err = a.checkUnknownKeys(doc, configs); err != nil {
+		return err
+	}
+
+	a.logHandler.ResetLevel(appConfig.Log.Level)
+	a.logHandler.ResetModuleLevels(appConfig.Log.Modules)
+	return a.watcher.Notify(append([]interface{}{appConfig}, configs...)...)
+}
+
+// watchReload reload config on change of config files if the watch interval is set
+func (a *_grape) watchReload() {
+	if a.configWatchInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(a.configWatchInterval)
+	defer ticker.Stop()
+	state := a.configFilesState()
+
+	for {
+		select {
+		case <-a.appContext.Done():
+			return
+		case <-ticker.C:
+			current := a.configFilesState()
+			if current == state {
+				continue
+			}
+			state = current
+			a.reload()
+		}
+	}
+}
+
+// reload reload config with notification of systemd, calls are serialized
+func (a *_grape) reload() {
+	a.reloadMux.Lock()
+	defer a.reloadMux.Unlock()
+
+	a.log.Info("Reload config")
+	a.notify(sdnotify.Reloading, sdnotify.Monotonic(), sdnotify.Status("Reloading config"))
+	started := time.Now()
+	err := a.reloadConfig()
+	lifecycle.Emit(a.events, lifecycle.ConfigReloaded, a.appName, started, err)
+	if err != nil {
+		a.log.Error("Reload config", "err", err)
+		a.notify(sdnotify.Ready, sdnotify.Status("Running, reload config failed: "+err.Error()))
+		return
+	}
+	a.log.Info("Config reloaded")
+	a.notify(sdnotify.Ready, sdnotify.Status("Running"))
+}
+
+// configFilesState build fingerprint of config files and their profiles by name, size and modification time
+func (a *_grape) configFilesState() string {
+	files, err := config2.ExpandFiles(a.configFiles...)
+	if err != nil {
+		return err.Error()
+	}
+	list := make([]string, 0, len(files)*2)
+	for _, filename := range files {
+		list = append(list, filename)
+		if profiles, err0 := filepath.Glob(config2.ProfileFile(filename, "*")); err0 == nil {
+			list = append(list, profiles...)
+		}
+	}
+	sort.Strings(list)
+
+	var sb strings.Builder
+	for _, filename := range list {
+		info, err0 := os.Stat(filename)
+		if err0 != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d;", filename, info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
+
+// copyModels create new config models with values of the templates
+func copyModels(vv []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(vv))
+	for _, v := range vv {
+		rv := reflect2.ValueOf(v)
+		if rv.Kind() != reflect2.Ptr {
+			result = append(result, v)
+			continue
+		}
+		cp := reflect2.New(rv.Elem().Type())
+		cp.Elem().Set(rv.Elem())
+		result = append(result, cp.Interface())
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	config2 "go.osspkg.com/grape/config"
+	"go.osspkg.com/logx"
+)
+
+func newTestApp(t *testing.T) *_grape {
+	a := New("test").(*_grape)
+	l, err := newLog("test", config2.LogConfig{Level: logx.LevelInfo, FilePath: filepath.Join(t.TempDir(), "app.log")})
+	casecheck.NoError(t, err)
+	a.logHandler, a.log = l, logx.New()
+	l.Handler(a.log)
+	t.Cleanup(func() { casecheck.NoError(t, l.Close()) })
+	return a
+}
+
+type testReloadConfig struct {
+	Name string `yaml:"name"`
+}
+
+func TestUnit_ReloadConfigChecks(t *testing.T) {
+	a := newTestApp(t)
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	a.ConfigFile(filename)
+	a.configTemplates = Modules{&testReloadConfig{}}
+
+	casecheck.NoError(t, os.WriteFile(filename, []byte("name: app\n"), 0600))
+	casecheck.NoError(t, a.reloadConfig())
+
+	casecheck.NoError(t, os.WriteFile(filename, []byte("name: app\nunknown: 1\n"), 0600))
+	casecheck.NoError(t, a.reloadConfig())
+	a.ConfigStrict(config2.StrictFail)
+	casecheck.ErrorContains(t, a.reloadConfig(), "unknown keys in config files: unknown")
+
+	casecheck.NoError(t, os.WriteFile(filename, []byte("listeners:\n  http:\n    network: tcp\n"), 0600))
+	casecheck.ErrorContains(t, a.reloadConfig(), "listeners.http.address")
+}
@@ -7,9 +7,13 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"go.osspkg.com/errors"
 	"go.osspkg.com/grape/errs"
+	"go.osspkg.com/grape/lifecycle"
 	"go.osspkg.com/syncing"
 	"go.osspkg.com/xc"
 )
@@ -70,16 +74,45 @@ func serviceCallDown(v interface{}) error {
 
 /**********************************************************************************************************************/
 
+const (
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateStopping = "stopping"
+	StateStopped  = "stopped"
+	StateFailed   = "failed"
+
+	StageUp   = "up"
+	StageDown = "down"
+)
+
 type (
+	// State lifecycle state of the service
+	State struct {
+		Name  string
+		State string
+	}
+	// Timing duration of one step of starting or stopping
+	Timing struct {
+		Stage    string
+		Name     string
+		Duration time.Duration
+		Err      error
+	}
 	item struct {
 		Previous *item
 		Current  interface{}
 		Next     *item
+		State    *State
 	}
 	_services struct {
-		tree   *item
-		status syncing.Switch
-		ctx    xc.Context
+		tree     *item
+		status   syncing.Switch
+		ctx      xc.Context
+		states   []*State
+		timings  []Timing
+		observer lifecycle.Observer
+		down     bool
+		mux      sync.Mutex
 	}
 	TServices interface {
 		IsOn() bool
@@ -88,6 +121,10 @@ type (
 		IterateOver()
 		AddAndUp(v interface{}) error
 		Down() error
+		Stopping() []string
+		States() []State
+		Timings() []Timing
+		Observe(o lifecycle.Observer)
 	}
 )
 
@@ -140,23 +177,35 @@ func (s *_services) AddAndUp(v interface{}) error {
 		return errors.Wrapf(errs.ErrServiceUnknown, "service [%T]", v)
 	}
 
+	state := s.addState(v)
 	if s.tree == nil {
 		s.tree = &item{
 			Previous: nil,
 			Current:  v,
 			Next:     nil,
+			State:    state,
 		}
 	} else {
 		n := &item{
 			Previous: s.tree,
 			Current:  v,
 			Next:     nil,
+			State:    state,
 		}
 		n.Previous.Next = n
 		s.tree = n
 	}
 
-	return serviceCallUp(v, s.ctx)
+	started := time.Now()
+	err := serviceCallUp(v, s.ctx)
+	s.addTiming(StageUp, state.Name, started, err)
+	lifecycle.Emit(s.observer, lifecycle.ServiceUp, state.Name, started, err)
+	if err != nil {
+		s.setState(state, StateFailed)
+		return err
+	}
+	s.setState(state, StateRunning)
+	return nil
 }
 
 // Down - stop all services
@@ -168,11 +217,22 @@ func (s *_services) Down() error {
 	if s.tree == nil {
 		return nil
 	}
+	s.mux.Lock()
+	s.down = true
+	s.mux.Unlock()
 	for {
-		if err := serviceCallDown(s.tree.Current); err != nil {
+		s.setState(s.tree.State, StateStopping)
+		started := time.Now()
+		err := serviceCallDown(s.tree.Current)
+		s.addTiming(StageDown, s.tree.State.Name, started, err)
+		lifecycle.Emit(s.observer, lifecycle.ServiceDown, s.tree.State.Name, started, err)
+		if err != nil {
 			err0 = errors.Wrap(err0,
 				errors.Wrapf(err, "down [%T] service error", s.tree.Current),
 			)
+			s.setState(s.tree.State, StateFailed)
+		} else {
+			s.setState(s.tree.State, StateStopped)
 		}
 		if s.tree.Previous == nil {
 			break
@@ -184,3 +244,74 @@ func (s *_services) Down() error {
 	}
 	return err0
 }
+
+// Stopping returns types of services which are not stopped yet, in order of stopping
+func (s *_services) Stopping() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]string, 0, len(s.states))
+	if !s.down {
+		return result
+	}
+	for i := len(s.states) - 1; i >= 0; i-- {
+		switch s.states[i].State {
+		case StateStopped, StateFailed:
+		default:
+			result = append(result, s.states[i].Name)
+		}
+	}
+	return result
+}
+
+// States returns lifecycle states of all services in order of starting
+func (s *_services) States() []State {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]State, 0, len(s.states))
+	for _, state := range s.states {
+		result = append(result, *state)
+	}
+	return result
+}
+
+func (s *_services) addState(v interface{}) *State {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	state := &State{Name: fmt.Sprintf("%T", v), State: StateStarting}
+	s.states = append(s.states, state)
+	return state
+}
+
+func (s *_services) setState(state *State, value string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if state != nil {
+		state.State = value
+	}
+}
+
+// Observe set observer of Up and Down of services, it is set before starting
+func (s *_services) Observe(o lifecycle.Observer) {
+	s.observer = o
+}
+
+// Timings returns durations of Up and Down calls of services
+func (s *_services) Timings() []Timing {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]Timing, len(s.timings))
+	copy(result, s.timings)
+	return result
+}
+
+func (s *_services) addTiming(stage, name string, started time.Time, err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.timings = append(s.timings, Timing{Stage: stage, Name: name, Duration: time.Since(started), Err: err})
+}
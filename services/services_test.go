@@ -0,0 +1,170 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/services"
+	"go.osspkg.com/xc"
+)
+
+type (
+	testServiceA struct{ up, down error }
+	testServiceB struct{ up, down error }
+	testServiceC struct{ release chan struct{} }
+)
+
+func (v *testServiceA) Up() error   { return v.up }
+func (v *testServiceA) Down() error { return v.down }
+func (v *testServiceB) Up() error   { return v.up }
+func (v *testServiceB) Down() error { return v.down }
+func (v *testServiceC) Up() error   { return nil }
+func (v *testServiceC) Down() error {
+	<-v.release
+	return nil
+}
+
+func TestUnit_States(t *testing.T) {
+	fail := errors.New("fail")
+	cases := []struct {
+		name      string
+		list      []interface{}
+		upErr     bool
+		afterUp   []string
+		downErr   bool
+		afterDown []string
+	}{
+		{
+			name:      "all running",
+			list:      []interface{}{&testServiceA{}, &testServiceB{}},
+			afterUp:   []string{services.StateRunning, services.StateRunning},
+			afterDown: []string{services.StateStopped, services.StateStopped},
+		},
+		{
+			name:      "up failed",
+			list:      []interface{}{&testServiceA{}, &testServiceB{up: fail}},
+			upErr:     true,
+			afterUp:   []string{services.StateRunning, services.StateFailed},
+			afterDown: []string{services.StateStopped, services.StateStopped},
+		},
+		{
+			name:      "down failed",
+			list:      []interface{}{&testServiceA{down: fail}, &testServiceB{}},
+			afterUp:   []string{services.StateRunning, services.StateRunning},
+			downErr:   true,
+			afterDown: []string{services.StateFailed, services.StateStopped},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := services.New(xc.New())
+			casecheck.NoError(t, srv.MakeAsUp())
+
+			var err error
+			for _, item := range c.list {
+				if err0 := srv.AddAndUp(item); err0 != nil {
+					err = err0
+				}
+			}
+			casecheck.Equal(t, c.upErr, err != nil)
+			casecheck.Equal(t, c.afterUp, stateValues(srv.States()))
+			casecheck.Equal(t, "*services_test.testServiceA", srv.States()[0].Name)
+			casecheck.Equal(t, 0, len(srv.Stopping()))
+
+			casecheck.Equal(t, c.downErr, srv.Down() != nil)
+			casecheck.Equal(t, c.afterDown, stateValues(srv.States()))
+			casecheck.Equal(t, 0, len(srv.Stopping()))
+		})
+	}
+}
+
+func TestUnit_Stopping(t *testing.T) {
+	srv := services.New(xc.New())
+	casecheck.NoError(t, srv.MakeAsUp())
+	slow := &testServiceC{release: make(chan struct{})}
+	casecheck.NoError(t, srv.AddAndUp(&testServiceA{}))
+	casecheck.NoError(t, srv.AddAndUp(slow))
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Down() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Stopping()) == 0 || srv.States()[1].State != services.StateStopping {
+		if time.Now().After(deadline) {
+			t.Fatal("service is not stopping")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	casecheck.Equal(t, []string{"*services_test.testServiceC", "*services_test.testServiceA"}, srv.Stopping())
+
+	close(slow.release)
+	casecheck.NoError(t, <-done)
+	casecheck.Equal(t, 0, len(srv.Stopping()))
+}
+
+func TestUnit_Timings(t *testing.T) {
+	fail := errors.New("fail")
+	type timing struct {
+		Stage string
+		Name  string
+		Err   bool
+	}
+	cases := []struct {
+		name string
+		list []interface{}
+		want []timing
+	}{
+		{
+			name: "up and down",
+			list: []interface{}{&testServiceA{}, &testServiceB{}},
+			want: []timing{
+				{Stage: services.StageUp, Name: "*services_test.testServiceA"},
+				{Stage: services.StageUp, Name: "*services_test.testServiceB"},
+				{Stage: services.StageDown, Name: "*services_test.testServiceB"},
+				{Stage: services.StageDown, Name: "*services_test.testServiceA"},
+			},
+		},
+		{
+			name: "errors",
+			list: []interface{}{&testServiceA{down: fail}, &testServiceB{up: fail}},
+			want: []timing{
+				{Stage: services.StageUp, Name: "*services_test.testServiceA"},
+				{Stage: services.StageUp, Name: "*services_test.testServiceB", Err: true},
+				{Stage: services.StageDown, Name: "*services_test.testServiceB"},
+				{Stage: services.StageDown, Name: "*services_test.testServiceA", Err: true},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := services.New(xc.New())
+			casecheck.NoError(t, srv.MakeAsUp())
+			for _, item := range c.list {
+				_ = srv.AddAndUp(item) // nolint: errcheck
+			}
+			_ = srv.Down() // nolint: errcheck
+
+			got := make([]timing, 0, len(c.want))
+			for _, item := range srv.Timings() {
+				casecheck.True(t, item.Duration >= 0)
+				got = append(got, timing{Stage: item.Stage, Name: item.Name, Err: item.Err != nil})
+			}
+			casecheck.Equal(t, c.want, got)
+		})
+	}
+}
+
+func stateValues(list []services.State) []string {
+	result := make([]string, 0, len(list))
+	for _, s := range list {
+		result = append(result, s.State)
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+type (
+	// Signals signals handled by the application,
+	// nil list - default signals are used, empty list - the action is disabled
+	Signals struct {
+		// Stop graceful shutdown, the second signal forces exit, default SIGINT, SIGTERM
+		Stop []os.Signal
+		// Reload reload config files, default SIGHUP
+		Reload []os.Signal
+		// Dump write goroutine stacks and state of services to the log or the dump directory, default SIGQUIT
+		Dump []os.Signal
+		// LogReopen reopen log files after external rotation, default SIGUSR1
+		LogReopen []os.Signal
+		// LogLevel raise the log level by cycle, default SIGUSR2
+		LogLevel []os.Signal
+		// Upgrade start the new copy of the application and stop the current one, default SIGTTIN
+		Upgrade []os.Signal
+	}
+
+	signalAction func(sig os.Signal)
+)
+
+func defaultSignals() Signals {
+	return Signals{
+		Stop:      []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		Reload:    []os.Signal{syscall.SIGHUP},
+		Dump:      []os.Signal{syscall.SIGQUIT},
+		LogReopen: []os.Signal{syscall.SIGUSR1},
+		LogLevel:  []os.Signal{syscall.SIGUSR2},
+		Upgrade:   []os.Signal{syscall.SIGTTIN},
+	}
+}
+
+// merge replace default signals with not nil lists
+func (v Signals) merge(src Signals) Signals {
+	for _, item := range []struct {
+		dst *[]os.Signal
+		src []os.Signal
+	}{
+		{&v.Stop, src.Stop},
+		{&v.Reload, src.Reload},
+		{&v.Dump, src.Dump},
+		{&v.LogReopen, src.LogReopen},
+		{&v.LogLevel, src.LogLevel},
+		{&v.Upgrade, src.Upgrade},
+	} {
+		if item.src != nil {
+			*item.dst = item.src
+		}
+	}
+	return v
+}
+
+// Signals set signals handled by the application
+func (a *_grape) Signals(s Signals) Grape {
+	a.signals = a.signals.merge(s)
+	return a
+}
+
+// ShutdownTimeout set deadline of stopping all services, 0 - wait without limit
+func (a *_grape) ShutdownTimeout(timeout time.Duration) Grape {
+	a.shutdownTimeout = timeout
+	return a
+}
+
+func (a *_grape) signalActions() (map[os.Signal]signalAction, error) {
+	result := make(map[os.Signal]signalAction)
+	for _, item := range []struct {
+		list   []os.Signal
+		action signalAction
+	}{
+		{a.signals.Stop, a.onStopSignal},
+		{a.signals.Reload, func(_ os.Signal) { a.reload() }},
+		{a.signals.Dump, func(_ os.Signal) { a.dumpState() }},
+		{a.signals.LogReopen, func(_ os.Signal) { a.reopenLog() }},
+		{a.signals.LogLevel, func(_ os.Signal) { a.logHandler.CycleLevel() }},
+		{a.signals.Upgrade, func(_ os.Signal) { a.startUpgrade() }},
+	} {
+		for _, sig := range item.list {
+			if _, ok := result[sig]; ok {
+				return nil, fmt.Errorf("signal [%s] is used for several actions", sig)
+			}
+			result[sig] = item.action
+		}
+	}
+	return result, nil
+}
+
+// watchSignals call actions of the received signals until the application is stopped
+func (a *_grape) watchSignals(actions map[os.Signal]signalAction) {
+	list := make([]os.Signal, 0, len(actions))
+	for sig := range actions {
+		list = append(list, sig)
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, list...)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-a.stopped:
+			return
+		case s := <-sig:
+			actions[s](s)
+		}
+	}
+}
+
+// onStopSignal the first signal stops the application gracefully, the second one forces exit
+func (a *_grape) onStopSignal(sig os.Signal) {
+	if atomic.AddInt32(&a.stopSignals, 1) == 1 {
+		a.log.Info("Stop signal received", "signal", sig.String())
+		a.appContext.Close()
+		return
+	}
+	a.log.Error("Forced exit", "signal", sig.String(), "stopping", strings.Join(a.packages.Stopping(), ", "))
+	a.logHandler.Flush()
+	a.forceExit(1)
+}
+
+// stopPackages stop all services, after the shutdown deadline the services still stopping are abandoned
+func (a *_grape) stopPackages() error {
+	if a.shutdownTimeout <= 0 {
+		return a.packages.Stop()
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.packages.Stop() }()
+
+	timer := time.NewTimer(a.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		names := strings.Join(a.packages.Stopping(), ", ")
+		a.abandoned = true
+		a.log.Error("Shutdown deadline exceeded, services are abandoned", "timeout", a.shutdownTimeout, "services", names)
+		return fmt.Errorf("shutdown deadline %s exceeded, abandoned services still stopping: %s",
+			a.shutdownTimeout, names)
+	}
+}
+
+func (a *_grape) reopenLog() {
+	if err := a.logHandler.Reopen(); err != nil {
+		a.log.Error("Reopen log files", "err", err)
+		return
+	}
+	a.log.Info("Log files reopened")
+}
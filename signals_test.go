@@ -0,0 +1,77 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+)
+
+type testSlowService struct {
+	stop chan struct{}
+}
+
+func (v *testSlowService) Up() error { return nil }
+func (v *testSlowService) Down() error {
+	<-v.stop
+	return nil
+}
+
+func TestUnit_SignalActions(t *testing.T) {
+	a := newTestApp(t)
+	a.Signals(Signals{Reload: []os.Signal{syscall.SIGUSR1}, LogReopen: []os.Signal{}})
+	actions, err := a.signalActions()
+	casecheck.NoError(t, err)
+	_, ok := actions[syscall.SIGHUP]
+	casecheck.False(t, ok)
+	_, ok = actions[syscall.SIGUSR1]
+	casecheck.True(t, ok)
+	_, ok = actions[syscall.SIGTERM]
+	casecheck.True(t, ok)
+
+	a.Signals(Signals{Dump: []os.Signal{syscall.SIGTERM}})
+	_, err = a.signalActions()
+	casecheck.Error(t, err)
+}
+
+func TestUnit_ForceExit(t *testing.T) {
+	a := newTestApp(t)
+	code := -1
+	a.forceExit = func(c int) { code = c }
+
+	a.onStopSignal(syscall.SIGTERM)
+	casecheck.Equal(t, -1, code)
+	select {
+	case <-a.appContext.Done():
+	default:
+		t.Fatal("application is not stopped")
+	}
+	a.onStopSignal(syscall.SIGINT)
+	casecheck.Equal(t, 1, code)
+}
+
+func TestUnit_ShutdownDeadline(t *testing.T) {
+	a := newTestApp(t)
+	srv := &testSlowService{stop: make(chan struct{})}
+	defer close(srv.stop)
+	a.ShutdownTimeout(50 * time.Millisecond)
+
+	casecheck.NoError(t, a.packages.Register(srv))
+	casecheck.NoError(t, a.packages.Start())
+
+	err := a.stopPackages()
+	casecheck.Error(t, err)
+	casecheck.True(t, strings.Contains(err.Error(), "*grape.testSlowService"))
+	casecheck.True(t, a.abandoned)
+	b, err := os.ReadFile(a.logHandler.conf.FilePath)
+	casecheck.NoError(t, err)
+	casecheck.True(t, strings.Contains(string(b), "services are abandoned"))
+}
@@ -0,0 +1,107 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const dumpFileMode = 0600
+
+// StateDumper writes stacks of all goroutines and state of services and dependencies,
+// it can be used by admin API, the application is not stopped
+type StateDumper interface {
+	DumpState(w io.Writer) error
+}
+
+// DumpDir set directory for files of state dumps, by default dumps are written to the log
+func (a *_grape) DumpDir(dir string) Grape {
+	a.dumpDir = dir
+	return a
+}
+
+func (a *_grape) DumpState(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "application: %s\npid: %d\ntime: %s\ngoroutines: %d\n",
+		a.appName, os.Getpid(), time.Now().Format(time.RFC3339Nano), runtime.NumGoroutine())
+
+	buf.WriteString("\nservices:\n")
+	for _, s := range a.packages.States() {
+		fmt.Fprintf(buf, "  %-8s %s\n", s.State, s.Name)
+	}
+
+	buf.WriteString("\nobjects:\n")
+	for _, o := range a.packages.Objects() {
+		initiated, service := "new", ""
+		if o.Initiated {
+			initiated = "created"
+		}
+		if o.Service {
+			service = " service"
+		}
+		fmt.Fprintf(buf, "  %-7s %s%s\n", initiated, o.Address, service)
+	}
+
+	buf.WriteString("\ngoroutines:\n")
+	buf.Write(goroutineStacks())
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// dumpState write the state dump to the file in the dump directory or to the log
+func (a *_grape) dumpState() {
+	if len(a.dumpDir) == 0 {
+		buf := &bytes.Buffer{}
+		if err := a.DumpState(buf); err != nil {
+			a.log.Error("Dump state", "err", err)
+			return
+		}
+		a.log.Info("State dump", "dump", buf.String())
+		return
+	}
+
+	filename := filepath.Join(a.dumpDir, a.appName+"-"+strconv.Itoa(os.Getpid())+"-"+
+		time.Now().Format("20060102T150405.000000")+".dump")
+	if err := a.writeDumpFile(filename); err != nil {
+		a.log.Error("Dump state", "err", err, "file", filename)
+		return
+	}
+	a.log.Info("State dump is written", "file", filename)
+}
+
+func (a *_grape) writeDumpFile(filename string) error {
+	if err := os.MkdirAll(a.dumpDir, 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, dumpFileMode)
+	if err != nil {
+		return err
+	}
+	err = a.DumpState(file)
+	if err0 := file.Close(); err == nil {
+		err = err0
+	}
+	return err
+}
+
+func goroutineStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
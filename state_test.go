@@ -0,0 +1,49 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.osspkg.com/casecheck"
+)
+
+type testStateService struct{}
+
+func (v *testStateService) Up() error   { return nil }
+func (v *testStateService) Down() error { return nil }
+
+func TestUnit_DumpState(t *testing.T) {
+	a := newTestApp(t)
+	casecheck.NoError(t, a.packages.Register(&testStateService{}, func() StateDumper { return a }))
+	casecheck.NoError(t, a.packages.Start())
+
+	buf := &bytes.Buffer{}
+	casecheck.NoError(t, a.DumpState(buf))
+	out := buf.String()
+	casecheck.True(t, strings.Contains(out, "application: test\n"))
+	casecheck.True(t, strings.Contains(out, "  running  *grape.testStateService\n"))
+	casecheck.True(t, strings.Contains(out, "  created *go.osspkg.com/grape.testStateService service\n"))
+	casecheck.True(t, strings.Contains(out, "  created go.osspkg.com/grape.StateDumper\n"))
+	casecheck.True(t, strings.Contains(out, "goroutine "))
+
+	dir := filepath.Join(t.TempDir(), "dumps")
+	a.DumpDir(dir)
+	a.dumpState()
+	list, err := filepath.Glob(filepath.Join(dir, "test-*.dump"))
+	casecheck.NoError(t, err)
+	casecheck.Equal(t, 1, len(list))
+	b, err := os.ReadFile(list[0])
+	casecheck.NoError(t, err)
+	casecheck.True(t, strings.Contains(string(b), "services:\n"))
+
+	casecheck.NoError(t, a.packages.Stop())
+	casecheck.Equal(t, "stopped", a.packages.States()[0].State)
+}
@@ -0,0 +1,38 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"time"
+
+	"go.osspkg.com/grape/internal/sdnotify"
+)
+
+// notify send state of the application to systemd, it does nothing without NOTIFY_SOCKET
+func (a *_grape) notify(states ...string) {
+	if err := a.notifier.Notify(states...); err != nil {
+		a.log.Warn("Notify systemd", "err", err)
+	}
+}
+
+// watchdog send keep-alive pings to systemd while the application is running, if WATCHDOG_USEC is set
+func (a *_grape) watchdog() {
+	interval, ok := sdnotify.WatchdogInterval()
+	if !ok || !a.notifier.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.appContext.Done():
+			return
+		case <-ticker.C:
+			a.notify(sdnotify.Watchdog)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.osspkg.com/grape/container"
+	"go.osspkg.com/grape/services"
+)
+
+const (
+	timingStartup  = "startup"
+	timingShutdown = "shutdown"
+)
+
+type (
+	timingReport struct {
+		Phase string       `json:"phase"`
+		Total float64      `json:"total_ms"`
+		Steps []timingStep `json:"steps"`
+	}
+	timingStep struct {
+		Stage    string  `json:"stage"`
+		Name     string  `json:"name"`
+		Duration float64 `json:"duration_ms"`
+		Error    string  `json:"error,omitempty"`
+	}
+)
+
+// SlowStepThreshold set duration of constructor call or Up/Down of service after which
+// the warning is written to the log, 0 - disabled
+func (a *_grape) SlowStepThreshold(threshold time.Duration) Grape {
+	a.slowStepThreshold = threshold
+	return a
+}
+
+// reportTimings write steps of the phase sorted by duration to the log as table and json
+func (a *_grape) reportTimings(phase string) {
+	stages := map[string]bool{services.StageDown: true}
+	if phase == timingStartup {
+		stages = map[string]bool{container.StageConstructor: true, services.StageUp: true}
+	}
+	list := make([]services.Timing, 0)
+	var total time.Duration
+	for _, t := range a.packages.Timings() {
+		if !stages[t.Stage] {
+			continue
+		}
+		list = append(list, t)
+		total += t.Duration
+		if a.slowStepThreshold > 0 && t.Duration > a.slowStepThreshold {
+			a.log.Warn("Slow "+phase+" step",
+				"stage", t.Stage, "name", t.Name, "duration", t.Duration.String(), "threshold", a.slowStepThreshold.String())
+		}
+	}
+	if len(list) == 0 {
+		return
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Duration > list[j].Duration })
+
+	report := timingReport{Phase: phase, Total: milliseconds(total), Steps: make([]timingStep, 0, len(list))}
+	table := &bytes.Buffer{}
+	fmt.Fprintf(table, "%12s  %-11s  %s\n", "DURATION", "STAGE", "NAME")
+	for _, t := range list {
+		step := timingStep{Stage: t.Stage, Name: t.Name, Duration: milliseconds(t.Duration)}
+		failed := ""
+		if t.Err != nil {
+			step.Error = t.Err.Error()
+			failed = " (failed)"
+		}
+		report.Steps = append(report.Steps, step)
+		fmt.Fprintf(table, "%12s  %-11s  %s%s\n", t.Duration.Round(time.Microsecond), t.Stage, t.Name, failed)
+	}
+	b, err := json.Marshal(report)
+	if err != nil {
+		a.log.Error("Timing report", "err", err)
+		return
+	}
+	a.log.Info("Timing report", "phase", phase, "total", total.String(), "table", table.String(), "json", string(b))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,56 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+)
+
+type testTimingService struct{}
+
+func (v *testTimingService) Up() error {
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+func (v *testTimingService) Down() error { return nil }
+
+func newTestTimingService() *testTimingService {
+	return &testTimingService{}
+}
+
+func TestUnit_TimingReport(t *testing.T) {
+	a := newTestApp(t)
+	a.SlowStepThreshold(10 * time.Millisecond)
+	casecheck.NoError(t, a.packages.Register(newTestTimingService))
+	casecheck.NoError(t, a.packages.Start())
+	a.reportTimings(timingStartup)
+	casecheck.NoError(t, a.packages.Stop())
+	a.reportTimings(timingShutdown)
+
+	list := a.packages.Timings()
+	casecheck.Equal(t, 3, len(list))
+	casecheck.Equal(t, "constructor", list[0].Stage)
+	casecheck.Equal(t, "go.osspkg.com/grape.newTestTimingService func() *grape.testTimingService", list[0].Name)
+	casecheck.Equal(t, "up", list[1].Stage)
+	casecheck.Equal(t, "*grape.testTimingService", list[1].Name)
+	casecheck.True(t, list[1].Duration >= 20*time.Millisecond)
+	casecheck.Equal(t, "down", list[2].Stage)
+
+	a.logHandler.Flush()
+	b, err := os.ReadFile(a.logHandler.conf.FilePath)
+	casecheck.NoError(t, err)
+	out := string(b)
+	casecheck.Equal(t, 1, strings.Count(out, "Slow startup step"))
+	casecheck.Equal(t, 2, strings.Count(out, "Timing report"))
+	casecheck.True(t, strings.Contains(out, `"phase":"startup"`))
+	casecheck.True(t, strings.Contains(out, `"phase":"shutdown"`))
+	casecheck.True(t, strings.Contains(out, "up           *grape.testTimingService"))
+}
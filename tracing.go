@@ -0,0 +1,41 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"go.osspkg.com/grape/tracing"
+)
+
+// Tracing enable spans of startup and shutdown: phase spans of steps with nested spans of constructors
+// and services, spans are exported after startup and after shutdown
+func (a *_grape) Tracing(exporter tracing.Exporter) Grape {
+	if a.tracer == nil {
+		a.tracer = tracing.NewTracer()
+		a.events.Observe(a.tracer)
+	}
+	a.traceExporter = exporter
+	return a
+}
+
+// traceStep call the step inside the phase span named by the step message
+func (a *_grape) traceStep(s step) error {
+	if a.tracer == nil || len(s.Message) == 0 {
+		return s.Call()
+	}
+	end := a.tracer.StartPhase(s.Message)
+	err := s.Call()
+	end(err)
+	return err
+}
+
+func (a *_grape) exportTrace() {
+	if a.tracer == nil || a.traceExporter == nil {
+		return
+	}
+	if err := a.traceExporter.Export(a.tracer.Spans()); err != nil {
+		a.log.Error("Export trace", "err", err)
+	}
+}
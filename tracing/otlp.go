@@ -0,0 +1,135 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package tracing
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+const (
+	scopeName = "go.osspkg.com/grape"
+
+	spanKindInternal = 1
+	statusOk         = 1
+	statusError      = 2
+)
+
+type (
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+
+	_fileExporter struct {
+		filename string
+		service  string
+	}
+)
+
+// NewFileExporter create exporter to the json file in OTLP format (ExportTraceServiceRequest),
+// it can be imported by Jaeger and other OTLP tools, the file is rewritten on every export
+func NewFileExporter(filename, service string) Exporter {
+	return &_fileExporter{filename: filename, service: service}
+}
+
+func (v *_fileExporter) Export(spans []Span) error {
+	b, err := json.Marshal(EncodeOTLP(v.service, spans))
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(v.filename), "."+filepath.Base(v.filename)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err0 := tmp.Close(); err == nil {
+		err = err0
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), v.filename)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name()) // nolint: errcheck
+	}
+	return err
+}
+
+// EncodeOTLP returns spans as OTLP json document of the service
+func EncodeOTLP(service string, spans []Span) interface{} {
+	list := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		item := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.Attributes),
+			Status:            otlpStatus{Code: statusOk},
+		}
+		if s.Err != nil {
+			item.Status = otlpStatus{Code: statusError, Message: s.Err.Error()}
+		}
+		list = append(list, item)
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: encodeAttributes(map[string]string{"service.name": service})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: scopeName},
+			Spans: list,
+		}},
+	}}}
+}
+
+func encodeAttributes(attrs map[string]string) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attrs))
+	for k, v := range attrs {
+		result = append(result, otlpAttribute{Key: k, Value: otlpValue{StringValue: v}})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
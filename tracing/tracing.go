@@ -0,0 +1,128 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.osspkg.com/grape/lifecycle"
+)
+
+// MaxReloadSpans count of the last config reloads kept by the tracer, older reload spans are removed
+const MaxReloadSpans = 16
+
+type (
+	// Span one step of startup or shutdown, all spans of the process have the same trace id,
+	// ParentID is empty for phase spans
+	Span struct {
+		TraceID    string
+		SpanID     string
+		ParentID   string
+		Name       string
+		Start      time.Time
+		End        time.Time
+		Attributes map[string]string
+		Err        error
+	}
+
+	// Exporter writes spans, it receives all spans collected since the start of the application
+	// except old config reloads
+	Exporter interface {
+		Export(spans []Span) error
+	}
+
+	// Tracer collects phase spans and spans of constructors and services from lifecycle events
+	Tracer struct {
+		traceID string
+		spans   []Span
+		phase   string
+		reloads int
+		mux     sync.Mutex
+	}
+)
+
+func NewTracer() *Tracer {
+	return &Tracer{traceID: newID(16)}
+}
+
+// StartPhase open the phase span, spans of constructors and services are nested under it until end is called
+func (t *Tracer) StartPhase(name string) (end func(err error)) {
+	span := Span{TraceID: t.traceID, SpanID: newID(8), Name: name, Start: time.Now()}
+
+	t.mux.Lock()
+	t.phase = span.SpanID
+	t.mux.Unlock()
+
+	return func(err error) {
+		span.End, span.Err = time.Now(), err
+
+		t.mux.Lock()
+		defer t.mux.Unlock()
+
+		if t.phase == span.SpanID {
+			t.phase = ""
+		}
+		t.spans = append(t.spans, span)
+	}
+}
+
+func (t *Tracer) OnEvent(e lifecycle.Event) {
+	switch e.Kind {
+	case lifecycle.ConstructorFinished, lifecycle.ServiceUp, lifecycle.ServiceDown, lifecycle.ConfigReloaded:
+	default:
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if e.Kind == lifecycle.ConfigReloaded {
+		if t.reloads >= MaxReloadSpans {
+			t.removeReload()
+		}
+		t.reloads++
+	}
+	t.spans = append(t.spans, Span{
+		TraceID:    t.traceID,
+		SpanID:     newID(8),
+		ParentID:   t.phase,
+		Name:       e.Name,
+		Start:      e.Time.Add(-e.Duration),
+		End:        e.Time,
+		Attributes: map[string]string{"grape.event": string(e.Kind)},
+		Err:        e.Err,
+	})
+}
+
+// removeReload remove the oldest span of config reload
+func (t *Tracer) removeReload() {
+	for i, span := range t.spans {
+		if span.Attributes["grape.event"] == string(lifecycle.ConfigReloaded) {
+			t.spans = append(t.spans[:i], t.spans[i+1:]...)
+			t.reloads--
+			return
+		}
+	}
+}
+
+// Spans returns finished spans in order of finishing
+func (t *Tracer) Spans() []Span {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	result := make([]Span, len(t.spans))
+	copy(result, t.spans)
+	return result
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b) // nolint: errcheck
+	return hex.EncodeToString(b)
+}
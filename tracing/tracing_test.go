@@ -0,0 +1,100 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/lifecycle"
+	"go.osspkg.com/grape/tracing"
+)
+
+func TestUnit_Tracer(t *testing.T) {
+	tracer := tracing.NewTracer()
+	end := tracer.StartPhase("Running dependencies")
+	lifecycle.Emit(tracer, lifecycle.ConstructorStarted, "pkg.New", time.Time{}, nil)
+	lifecycle.Emit(tracer, lifecycle.ConstructorFinished, "pkg.New", time.Now().Add(-time.Millisecond), nil)
+	lifecycle.Emit(tracer, lifecycle.ServiceUp, "*pkg.Service", time.Now(), errors.New("fail"))
+	end(nil)
+	lifecycle.Emit(tracer, lifecycle.ConfigReloaded, "app", time.Now(), nil)
+
+	spans := tracer.Spans()
+	casecheck.Equal(t, 4, len(spans))
+	phase := spans[2]
+	casecheck.Equal(t, "Running dependencies", phase.Name)
+	casecheck.Equal(t, "", phase.ParentID)
+	casecheck.Equal(t, phase.SpanID, spans[0].ParentID)
+	casecheck.Equal(t, phase.SpanID, spans[1].ParentID)
+	casecheck.Equal(t, "", spans[3].ParentID)
+	casecheck.Equal(t, "pkg.New", spans[0].Name)
+	casecheck.True(t, spans[0].End.Sub(spans[0].Start) >= time.Millisecond)
+	for _, s := range spans {
+		casecheck.Equal(t, phase.TraceID, s.TraceID)
+		casecheck.Equal(t, 32, len(s.TraceID))
+		casecheck.Equal(t, 16, len(s.SpanID))
+	}
+
+	filename := filepath.Join(t.TempDir(), "trace.json")
+	casecheck.NoError(t, tracing.NewFileExporter(filename, "app").Export(spans))
+	b, err := os.ReadFile(filename)
+	casecheck.NoError(t, err)
+
+	var doc struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []struct {
+					SpanID            string `json:"spanId"`
+					ParentSpanID      string `json:"parentSpanId"`
+					Name              string `json:"name"`
+					StartTimeUnixNano string `json:"startTimeUnixNano"`
+					Status            struct {
+						Code    int    `json:"code"`
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	casecheck.NoError(t, json.Unmarshal(b, &doc))
+	casecheck.Equal(t, "service.name", doc.ResourceSpans[0].Resource.Attributes[0].Key)
+	casecheck.Equal(t, "app", doc.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	list := doc.ResourceSpans[0].ScopeSpans[0].Spans
+	casecheck.Equal(t, 4, len(list))
+	casecheck.Equal(t, phase.SpanID, list[1].ParentSpanID)
+	casecheck.Equal(t, 2, list[1].Status.Code)
+	casecheck.Equal(t, "fail", list[1].Status.Message)
+	casecheck.Equal(t, 1, list[0].Status.Code)
+	casecheck.True(t, len(list[0].StartTimeUnixNano) > 0)
+}
+
+func TestUnit_TracerReloadLimit(t *testing.T) {
+	tracer := tracing.NewTracer()
+	lifecycle.Emit(tracer, lifecycle.ServiceUp, "*pkg.Service", time.Time{}, nil)
+	for i := 0; i < tracing.MaxReloadSpans+10; i++ {
+		lifecycle.Emit(tracer, lifecycle.ConfigReloaded, "reload "+strconv.Itoa(i), time.Time{}, nil)
+	}
+
+	spans := tracer.Spans()
+	casecheck.Equal(t, tracing.MaxReloadSpans+1, len(spans))
+	casecheck.Equal(t, "*pkg.Service", spans[0].Name)
+	casecheck.Equal(t, "reload 10", spans[1].Name)
+	casecheck.Equal(t, "reload "+strconv.Itoa(tracing.MaxReloadSpans+9), spans[len(spans)-1].Name)
+}
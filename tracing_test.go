@@ -0,0 +1,60 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"testing"
+
+	"go.osspkg.com/casecheck"
+	"go.osspkg.com/grape/tracing"
+)
+
+type testTraceExporter struct {
+	spans []tracing.Span
+}
+
+func (v *testTraceExporter) Export(spans []tracing.Span) error {
+	v.spans = spans
+	return nil
+}
+
+func TestUnit_Tracing(t *testing.T) {
+	a := newTestApp(t)
+	exporter := &testTraceExporter{}
+	a.Tracing(exporter)
+	a.modules = a.modules.Add(newTestTimingService)
+
+	a.steps(
+		[]step{
+			{Message: "Registering dependencies", Call: func() error { return a.packages.Register(a.modules...) }},
+			{Message: "Running dependencies", Call: a.packages.Start},
+		},
+		func(_ bool) {},
+		[]step{
+			{Message: "Stop dependencies", Call: a.packages.Stop},
+		},
+	)
+	a.exportTrace()
+
+	phases := make(map[string]string)
+	for _, s := range exporter.spans {
+		if len(s.ParentID) == 0 {
+			phases[s.SpanID] = s.Name
+		}
+	}
+	names := make(map[string]string)
+	for _, s := range exporter.spans {
+		if len(s.ParentID) > 0 {
+			names[s.Attributes["grape.event"]] = phases[s.ParentID]
+		}
+	}
+	casecheck.Equal(t, 3, len(phases))
+	casecheck.Equal(t, map[string]string{
+		"constructor-finished": "Running dependencies",
+		"service-up":           "Running dependencies",
+		"service-down":         "Stop dependencies",
+	}, names)
+}
@@ -0,0 +1,102 @@
+/*
+ *  Copyright (c) 2024 Mikhail Knyazhev <markus621@yandex.ru>. All rights reserved.
+ *  Use of this source code is governed by a BSD 3-Clause license that can be found in the LICENSE file.
+ */
+
+package grape
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.osspkg.com/grape/internal/pidfile"
+	"go.osspkg.com/grape/internal/sdnotify"
+	"go.osspkg.com/grape/internal/upgrade"
+)
+
+const upgradeTimeout = time.Minute
+
+// startUpgrade start the new copy of the application in background, the current process stops
+// after the new one is ready
+func (a *_grape) startUpgrade() {
+	if !atomic.CompareAndSwapInt32(&a.upgrading, 0, 1) {
+		a.log.Warn("Upgrade application is already in progress")
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&a.upgrading, 0)
+		if err := a.upgrade(); err != nil {
+			a.log.Error("Upgrade application", "err", err)
+		}
+	}()
+}
+
+func (a *_grape) upgrade() error {
+	a.log.Info("Upgrade application")
+	files, err := a.listeners.Files()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.File.Close() // nolint: errcheck
+		}
+	}()
+	var pid *os.File
+	if a.pidFile != nil {
+		pid = a.pidFile.File()
+	}
+
+	child, err := upgrade.Start(files, pid, a.restarts)
+	if err != nil {
+		return err
+	}
+	a.log.Info("Wait for the new process", "pid", child.Pid())
+	if err = child.WaitReady(upgradeTimeout); err != nil {
+		return err
+	}
+	a.log.Info("New process is ready, stopping", "pid", child.Pid())
+
+	a.upgraded = true
+	a.listeners.Detach()
+	// the child reports readiness to systemd only after it is the main process
+	a.notify(sdnotify.MainPID(child.Pid()))
+	if err = child.Confirm(); err != nil {
+		a.log.Error("Confirm the new process", "err", err, "pid", child.Pid())
+	}
+	a.appContext.Close()
+	return nil
+}
+
+// inherit take listeners and the pid file from the parent process if the application is started by upgrade
+func (a *_grape) inherit() error {
+	inherited, err := upgrade.Inherited()
+	if err != nil || inherited == nil {
+		return err
+	}
+	a.inherited = inherited
+	a.restarts = inherited.Restarts
+	return a.listeners.addFiles(inherited.Listeners, listenerSourceUpgrade)
+}
+
+// completeUpgrade take the pid file and report readiness to the parent process after successful start
+func (a *_grape) completeUpgrade() {
+	if a.inherited == nil {
+		return
+	}
+	defer func() {
+		a.inherited.Close()
+		a.inherited = nil
+	}()
+	if a.inherited.PidFile != nil && len(a.pidFilePath) > 0 {
+		var err error
+		if a.pidFile, err = pidfile.Inherit(a.pidFilePath, a.inherited.PidFile); err != nil {
+			a.log.Error("Inherit pid file", "err", err, "file", a.pidFilePath)
+		}
+		a.inherited.PidFile = nil
+	}
+	if err := a.inherited.Ready(); err != nil {
+		a.log.Error("Report ready to the parent process", "err", err)
+	}
+}